@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"  // for formatted I/O
+	"time" // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// runAOFCompare measures what durability costs on the write path: the same
+// serial SET + HSET insertion runs under appendfsync everysec and then
+// always, and the original appendfsync setting is restored afterwards.
+func runAOFCompare(rdb redis.UniversalClient, cfg Config) error {
+	aof, err := getConfig(rdb, "appendonly")
+	if err != nil {
+		return err
+	}
+	if aof != "yes" {
+		fmt.Println("⚠️  AOF is disabled (appendonly no): appendfsync has no effect, expect equal numbers")
+	}
+
+	n := cfg.WorkloadSize
+	fmt.Println("Write durability: appendfsync")
+	fmt.Println("Policy    | Records | Insert         | Records/s")
+	fmt.Println("----------+---------+----------------+-----------")
+	rates := map[string]float64{}
+	for _, policy := range []string{"everysec", "always"} {
+		dur, err := timedAOFInsert(rdb, cfg, policy, n)
+		if err != nil {
+			return err
+		}
+		rates[policy] = float64(n) / dur.Seconds()
+		fmt.Printf("%-9s | %7d | %14v | %10.0f\n", policy, n, dur, rates[policy])
+	}
+	if rates["always"] > 0 {
+		fmt.Printf("  everysec is %.2fx the throughput of always\n",
+			rates["everysec"]/rates["always"])
+	}
+	return nil
+}
+
+// timedAOFInsert inserts n records with appendfsync set to policy and
+// deletes them again, returning the insertion time.
+func timedAOFInsert(rdb redis.UniversalClient, cfg Config, policy string, n int) (time.Duration, error) {
+	restore, err := setConfig(rdb, "appendfsync", policy)
+	if err != nil {
+		return 0, err
+	}
+	t0 := time.Now()
+	ds, err := insertRecords(rdb, cfg, &recordSource{}, n)
+	dur := time.Since(t0)
+	if rerr := restore(); rerr != nil && err == nil {
+		err = rerr
+	}
+	if cerr := deleteInsertedKeys(rdb, ds.distinct); cerr != nil && err == nil {
+		err = cerr
+	}
+	return dur, err
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json" // for the baseline file
+	"fmt"           // for formatted errors
+	"os"            // for reading and writing the baseline file
+	"time"          // for run timestamps
+)
+
+// saveBaseline writes results to path as a RunRecord, the same document
+// recordResults stores in the history stream, for a later
+// -compare-baseline.
+func saveBaseline(path string, results []BenchResult) error {
+	run := RunRecord{
+		SchemaVersion: resultsSchemaVersion,
+		Timestamp:     time.Now().UTC(),
+		ClientVersion: clientVersion(),
+		Results:       results,
+	}
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal baseline: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write baseline: %w", err)
+	}
+	return nil
+}
+
+// loadBaseline reads a file written by saveBaseline.
+func loadBaseline(path string) (*RunRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read baseline: %w", err)
+	}
+	var run RunRecord
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("parse baseline %s: %w", path, err)
+	}
+	return &run, nil
+}
+
+// compareBaseline prints how results compare to the baseline in path and
+// returns the largest slowdown in percent. A baseline from another results
+// schema is still compared, with a warning, since the fields it shares
+// with this build keep their meaning.
+func compareBaseline(path string, results []BenchResult) (float64, error) {
+	base, err := loadBaseline(path)
+	if err != nil {
+		return 0, err
+	}
+	if base.SchemaVersion != resultsSchemaVersion {
+		fmt.Printf("⚠️  baseline uses results schema %d, this build writes %d; direct times may not be comparable\n",
+			base.SchemaVersion, resultsSchemaVersion)
+	}
+	fmt.Printf("Compared with baseline %s (%s, go-redis %s):\n",
+		path, base.Timestamp.Format(time.RFC3339), base.ClientVersion)
+	return compareResults(base.Results, results), nil
+}
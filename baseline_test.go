@@ -0,0 +1,93 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBaselineRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "base.json")
+	want := []BenchResult{{Count: 100, Direct: 3 * time.Millisecond, Lua: time.Millisecond, Encoding: "gob"}}
+	if err := saveBaseline(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.SchemaVersion != resultsSchemaVersion {
+		t.Errorf("schema version %d, want %d", got.SchemaVersion, resultsSchemaVersion)
+	}
+	if len(got.Results) != 1 || got.Results[0].Count != 100 || got.Results[0].Direct != want[0].Direct ||
+		got.Results[0].Lua != want[0].Lua || got.Results[0].Encoding != "gob" {
+		t.Errorf("loaded %+v, want %+v", got.Results, want)
+	}
+	if _, err := loadBaseline(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("loading a missing baseline succeeded")
+	}
+}
+
+func TestCompareResults(t *testing.T) {
+	prev := []BenchResult{
+		{Count: 100, Direct: 10 * time.Millisecond, Pipeline: 10 * time.Millisecond},
+		{Count: 1000, Direct: 100 * time.Millisecond},
+	}
+	cur := []BenchResult{
+		{Count: 100, Direct: 9 * time.Millisecond, Pipeline: 11240 * time.Microsecond, Lua: time.Millisecond},
+		{Count: 5000, Direct: time.Second},
+	}
+	var worst float64
+	out := captureStdout(t, func() { worst = compareResults(prev, cur) })
+
+	if worst < 12.39 || worst > 12.41 {
+		t.Errorf("worst slowdown %.2f%%, want 12.4%%", worst)
+	}
+	for _, want := range []string{"11.24ms +12.4% slower", "9ms -10.0% faster", "[5000] only in this run", "[1000] only in the earlier one"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	// Only the overlapping size gets a row; Lua has no baseline to compare
+	if strings.Contains(out, "  5000 |") || strings.Contains(out, "  1000 |") {
+		t.Errorf("a size missing from one side got a row:\n%s", out)
+	}
+	if strings.Contains(out, "1ms") {
+		t.Errorf("Lua was compared without a baseline value:\n%s", out)
+	}
+
+	out = captureStdout(t, func() { worst = compareResults(cur, cur) })
+	if worst != 0 {
+		t.Errorf("comparing a run with itself gave worst slowdown %.1f%%, want 0", worst)
+	}
+	if strings.Contains(out, "slower") || strings.Contains(out, "faster") {
+		t.Errorf("an unchanged time is called slower or faster:\n%s", out)
+	}
+}
+
+func TestPersistResultsReportsRegression(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.json")
+	if err := saveBaseline(base, []BenchResult{{Count: 10, Direct: 10 * time.Millisecond}}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{ResultsDB: -1, SaveBaseline: filepath.Join(dir, "new.json"), CompareBaseline: base, FailOnRegress: 5}
+
+	var regression string
+	captureStdout(t, func() {
+		regression = persistResults(cfg, []BenchResult{{Count: 10, Direct: 12 * time.Millisecond}})
+	})
+	if regression == "" {
+		t.Error("a 20% slowdown did not trip -fail-on-regress 5")
+	}
+	if _, err := loadBaseline(cfg.SaveBaseline); err != nil {
+		t.Errorf("the regressed run was not saved: %v", err)
+	}
+	captureStdout(t, func() {
+		regression = persistResults(cfg, []BenchResult{{Count: 10, Direct: 10 * time.Millisecond}})
+	})
+	if regression != "" {
+		t.Error("an unchanged run tripped -fail-on-regress")
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPipelineFetchCommandCount(t *testing.T) {
+	m, rdb := newTestRedis(t)
+	const n = 103
+	ds := insertTestRecords(t, rdb, n)
+
+	// Batch sizes that divide n, don't, exceed it, and degenerate to 1
+	for _, batch := range []int{1, 10, n, 1000} {
+		before := m.CommandCount()
+		if _, err := pipelineFetch(batch)(ctx, rdb, ds.jsonKeys, ds.hashKeys); err != nil {
+			t.Fatalf("batch %d: %v", batch, err)
+		}
+		if got := m.CommandCount() - before; got != 2*n {
+			t.Errorf("batch %d: executed %d commands, want %d", batch, got, 2*n)
+		}
+	}
+}
+
+func TestBatchFetchExpiredKeys(t *testing.T) {
+	m, rdb := newTestRedis(t)
+	ds := insertTestRecords(t, rdb, 5)
+	m.Del(ds.jsonKeys[2]) // as if its -ttl ran out
+
+	if _, err := batchFetch(false)(ctx, rdb, ds.jsonKeys, ds.hashKeys); err == nil {
+		t.Error("batch fetch without -ttl accepted a missing inserted key")
+	}
+	if _, err := batchFetch(true)(ctx, rdb, ds.jsonKeys, ds.hashKeys); err != nil {
+		t.Errorf("batch fetch with -ttl failed on an expired key: %v", err)
+	}
+}
+
+func TestWarmupIsNotRecorded(t *testing.T) {
+	m, rdb := newTestRedis(t)
+	const n = 10
+	ds := insertTestRecords(t, rdb, n)
+	cfg := Config{Warmup: 3, Runs: 2}
+
+	// Every warmup pass is a full fetch of the dataset
+	before := m.CommandCount()
+	if err := warmup(cfg, "pipeline", pipelineFetch(4), rdb, ds); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.CommandCount() - before; got != cfg.Warmup*2*n {
+		t.Errorf("warmup executed %d commands, want %d", got, cfg.Warmup*2*n)
+	}
+
+	// Records lost during warmup are not added to the phase's failures
+	rdb.AddHook(failHook{"get"})
+	var res BenchResult
+	res.Count = n
+	if err := warmup(cfg, "direct", fetchDirect, rdb, ds); err != nil {
+		t.Fatalf("warmup failed on partial fetches: %v", err)
+	}
+	if _, err := runPhase(cfg, &res, "direct", fetchDirect, rdb, ds); err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Failed["direct"]; got != cfg.Runs*n {
+		t.Errorf("Failed[direct] = %d, want %d from the measured passes only", got, cfg.Runs*n)
+	}
+	if got := res.Stats["direct"].Runs; got != cfg.Runs {
+		t.Errorf("Stats[direct].Runs = %d, want %d", got, cfg.Runs)
+	}
+	if got := res.Attempted["direct"]; got != cfg.Runs*n {
+		t.Errorf("Attempted[direct] = %d, want %d", got, cfg.Runs*n)
+	}
+
+	// A phase cut off after a partial pass has no Stats, but its failures
+	// are still reported out of the records it tried
+	res.Stats, res.TimedOut = nil, []string{"direct"}
+	if got, want := res.failures(), []string{"direct: 20/20 fetches failed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("failures() = %q, want %q", got, want)
+	}
+}
+
+func TestDirectVariantsDecode(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ds := insertTestRecords(t, rdb, 8)
+	if err := rdb.Set(ctx, ds.jsonKeys[2], "not a record", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Encoding: "json", Workers: 2, PipeBatch: 4, Duration: 20 * time.Millisecond}
+
+	// The concurrent column decodes like the counted direct one
+	_, err := concurrentFetch(decodingFetch(cfg), cfg.Workers)(ctx, rdb, ds.jsonKeys, ds.hashKeys)
+	var partial *PartialFetchError
+	if !errors.As(err, &partial) || partial.Failed != 1 {
+		t.Errorf("concurrent fetch with one undecodable value: %v, want 1 failed record", err)
+	}
+
+	// and so does -duration's direct
+	strategies, err := selectStrategies("direct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Strategies = strategies
+	timed, err := runTimedFetches(cfg, rdb, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timed) != 1 || timed[0].Failed == 0 {
+		t.Errorf("timed direct = %+v, want failures for the undecodable value", timed)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json" // for the JSON representation
+	"fmt"           // for formatted I/O
+	"strconv"       // for parsing string-stored integers
+	"time"          // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// packedAttrs are the small per-record integers the bitfield workload
+// stores. Widths are chosen to fit their ranges: amount < 2^17,
+// visits < 2^16 and tier < 2^4, so one record packs into 37 bits.
+type packedAttrs struct {
+	Amount int64 `json:"amount"`
+	Visits int64 `json:"visits"`
+	Tier   int64 `json:"tier"`
+}
+
+// bitfieldLayout is the BITFIELD type and absolute bit offset of each
+// attribute, in packedAttrs field order.
+var bitfieldLayout = []struct {
+	typ    string
+	offset int
+}{
+	{"u17", 0},
+	{"u16", 17},
+	{"u4", 33},
+}
+
+// bitfieldRep is one storage layout compared by runBitfield.
+type bitfieldRep struct {
+	name  string                                               // label printed in the table
+	keys  func(id string) []string                             // every key written for id
+	write func(pipe redis.Pipeliner, id string, a packedAttrs) // queues the writes for id
+	read  func(id string) (packedAttrs, error)                 // reads id back
+}
+
+// runBitfield stores the same attributes three ways — packed into one
+// string with BITFIELD, as one key per attribute, and as a JSON string —
+// and compares memory per record and read time for each.
+func runBitfield(rdb redis.UniversalClient, cfg Config) error {
+	n := cfg.WorkloadSize
+	ids := make([]string, n)
+	attrs := make([]packedAttrs, n)
+	for i := range ids {
+		rec := generateRecord()
+		ids[i] = rec.ID
+		attrs[i] = packedAttrs{
+			Amount: int64(rec.Amount),
+			Visits: int64(randInt(0, 1<<16)),
+			Tier:   int64(randInt(0, 1<<4)),
+		}
+	}
+
+	reps := []bitfieldRep{
+		{
+			name: "BITFIELD",
+			keys: func(id string) []string { return []string{keyPrefix + "bits:" + id} },
+			write: func(pipe redis.Pipeliner, id string, a packedAttrs) {
+				args := []interface{}{}
+				for i, v := range []int64{a.Amount, a.Visits, a.Tier} {
+					args = append(args, "SET", bitfieldLayout[i].typ, bitfieldLayout[i].offset, v)
+				}
+				pipe.BitField(ctx, keyPrefix+"bits:"+id, args...)
+			},
+			read: func(id string) (packedAttrs, error) {
+				args := []interface{}{}
+				for _, f := range bitfieldLayout {
+					args = append(args, "GET", f.typ, f.offset)
+				}
+				vals, err := rdb.BitField(ctx, keyPrefix+"bits:"+id, args...).Result()
+				if err != nil {
+					return packedAttrs{}, err
+				}
+				return packedAttrs{Amount: vals[0], Visits: vals[1], Tier: vals[2]}, nil
+			},
+		},
+		{
+			name: "separate keys",
+			keys: separateAttrKeys,
+			write: func(pipe redis.Pipeliner, id string, a packedAttrs) {
+				k := separateAttrKeys(id)
+				pipe.MSet(ctx, k[0], a.Amount, k[1], a.Visits, k[2], a.Tier)
+			},
+			read: func(id string) (packedAttrs, error) {
+				vals, err := rdb.MGet(ctx, separateAttrKeys(id)...).Result()
+				if err != nil {
+					return packedAttrs{}, err
+				}
+				var out [3]int64
+				for i, v := range vals {
+					s, _ := v.(string)
+					if out[i], err = strconv.ParseInt(s, 10, 64); err != nil {
+						return packedAttrs{}, fmt.Errorf("attribute %d: %w", i, err)
+					}
+				}
+				return packedAttrs{Amount: out[0], Visits: out[1], Tier: out[2]}, nil
+			},
+		},
+		{
+			name: "JSON",
+			keys: func(id string) []string { return []string{keyPrefix + "bitjson:" + id} },
+			write: func(pipe redis.Pipeliner, id string, a packedAttrs) {
+				data, _ := json.Marshal(a)
+				pipe.Set(ctx, keyPrefix+"bitjson:"+id, data, 0)
+			},
+			read: func(id string) (packedAttrs, error) {
+				var a packedAttrs
+				data, err := rdb.Get(ctx, keyPrefix+"bitjson:"+id).Bytes()
+				if err != nil {
+					return a, err
+				}
+				return a, json.Unmarshal(data, &a)
+			},
+		},
+	}
+
+	fmt.Println("Packed integers: BITFIELD vs separate keys vs JSON")
+	fmt.Println("Layout        | Bytes/rec | Read           | Per read")
+	fmt.Println("--------------+-----------+----------------+-----------")
+	var jsonBytes float64
+	perRec := make([]float64, len(reps))
+	for r, rep := range reps {
+		bytes, dur, err := measureBitfieldRep(rdb, rep, ids, attrs)
+		if err != nil {
+			return err
+		}
+		perRec[r] = bytes
+		if rep.name == "JSON" {
+			jsonBytes = perRec[r]
+		}
+		fmt.Printf("%-13s | %9.1f | %14v | %v\n",
+			rep.name, perRec[r], dur, dur/time.Duration(n))
+	}
+	if perRec[0] > 0 {
+		fmt.Printf("  BITFIELD packing: %.1fx smaller than JSON, %.1fx smaller than separate keys\n",
+			jsonBytes/perRec[0], perRec[1]/perRec[0])
+	}
+	return nil
+}
+
+// measureBitfieldRep writes attrs for ids in rep's layout, reads every
+// record back, and deletes its keys before returning the memory per record
+// and the total read time.
+func measureBitfieldRep(rdb redis.UniversalClient, rep bitfieldRep, ids []string, attrs []packedAttrs) (float64, time.Duration, error) {
+	var keys []string
+	for _, id := range ids {
+		keys = append(keys, rep.keys(id)...)
+	}
+	defer deleteInsertedKeys(rdb, keys)
+
+	before, err := getMemory(rdb)
+	if err != nil {
+		return 0, 0, err
+	}
+	pipe := rdb.Pipeline()
+	for i, id := range ids {
+		rep.write(pipe, id, attrs[i])
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, 0, fmt.Errorf("%s write failed: %w", rep.name, err)
+	}
+	after, err := getMemory(rdb)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	t0 := time.Now()
+	for i, id := range ids {
+		got, err := rep.read(id)
+		if err == nil && got != attrs[i] {
+			err = fmt.Errorf("read %+v, want %+v", got, attrs[i])
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("%s read of %s failed: %w", rep.name, id, err)
+		}
+	}
+	return float64(after-before) / float64(len(ids)), time.Since(t0), nil
+}
+
+// separateAttrKeys returns the one-key-per-attribute layout for id.
+func separateAttrKeys(id string) []string {
+	p := keyPrefix + "bitsep:" + id
+	return []string{p + ":amount", p + ":visits", p + ":tier"}
+}
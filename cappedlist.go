@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json" // for marshaling Record structs
+	"fmt"           // for formatted I/O
+	"strconv"       // for naming list keys
+	"time"          // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// runCappedList benchmarks the "keep the last K items" pattern: every new
+// record is LPUSHed and the list immediately LTRIMmed back to K entries.
+// Each LPUSH+LTRIM pair is sent as one pipeline, as an activity feed would.
+func runCappedList(rdb redis.UniversalClient, cfg Config) error {
+	n := cfg.WorkloadSize
+	fmt.Println("Capped list: LPUSH + LTRIM")
+	fmt.Println("Cap     | Pushes | Duration       | Pairs/s    | Mem (bytes)")
+	fmt.Println("--------+--------+----------------+------------+------------")
+
+	for _, limit := range cfg.ListCaps {
+		if err := runCap(rdb, n, limit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runCap fills one list capped at limit, checks it holds exactly the cap
+// and prints its row. The list is deleted however runCap returns.
+func runCap(rdb redis.UniversalClient, n, limit int) error {
+	key := keyPrefix + "capped:" + strconv.Itoa(limit)
+	defer deleteInsertedKeys(rdb, []string{key})
+	dur, err := pushCapped(rdb, key, n, limit)
+	if err != nil {
+		return err
+	}
+
+	// Steady state: the list must never exceed the cap
+	length, err := rdb.LLen(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("LLEN %s failed: %w", key, err)
+	}
+	want := int64(limit)
+	if n < limit {
+		want = int64(n)
+	}
+	if length != want {
+		return fmt.Errorf("list %s has %d entries, want %d", key, length, want)
+	}
+	mem, err := rdb.MemoryUsage(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("MEMORY USAGE %s failed: %w", key, err)
+	}
+
+	fmt.Printf("%7d | %6d | %14v | %10.0f | %11d\n",
+		limit, n, dur, float64(n)/dur.Seconds(), mem)
+	return nil
+}
+
+// pushCapped pushes n generated records onto key, trimming it to limit
+// entries after every push, and returns the total time taken.
+func pushCapped(rdb redis.UniversalClient, key string, n, limit int) (time.Duration, error) {
+	t0 := time.Now()
+	for i := 0; i < n; i++ {
+		data, _ := json.Marshal(generateRecord())
+		pipe := rdb.Pipeline()
+		pipe.LPush(ctx, key, data)
+		pipe.LTrim(ctx, key, 0, int64(limit-1))
+		if _, err := pipe.Exec(ctx); err != nil {
+			return 0, fmt.Errorf("LPUSH+LTRIM %s failed: %w", key, err)
+		}
+	}
+	return time.Since(t0), nil
+}
@@ -0,0 +1,16 @@
+package main
+
+import "testing"
+
+func TestCappedListCleansUpOnError(t *testing.T) {
+	for _, cmd := range []string{"llen", "memory"} {
+		m, rdb := newTestRedis(t)
+		rdb.AddHook(failHook{cmd})
+		if err := runCappedList(rdb, Config{WorkloadSize: 5, ListCaps: []int{3}}); err == nil {
+			t.Errorf("%s: expected the injected failure", cmd)
+		}
+		if keys := m.Keys(); len(keys) != 0 {
+			t.Errorf("%s failure left keys %v", cmd, keys)
+		}
+	}
+}
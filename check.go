@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"         // for the in-memory record source
+	"encoding/json" // for encoding the expected records
+	"fmt"           // for formatted I/O
+	"strings"       // for joining field differences
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// checkRecords is the dataset size used by -check.
+const checkRecords = 10
+
+// fetchedRecord is what a strategy read back for one record.
+type fetchedRecord struct {
+	json  string // value of the JSON string key
+	email string // "email" field of the hash key
+}
+
+// runCheck is the -check correctness gate. It inserts checkRecords records
+// through the normal insert path, reads them back with the direct,
+// pipeline and Lua strategies, and compares every value field by field
+// against the source record and across strategies; every hash, read with
+// HGETALL, must rebuild the source record. Mismatches are printed and
+// reported as an error; the inserted keys are deleted either way.
+func runCheck(rdb redis.UniversalClient, cfg Config) error {
+	// Replay known records through insertRecords so the write path is the
+	// one the benchmark uses
+	want := make([]Record, checkRecords)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range want {
+		want[i] = generateRecord()
+		enc.Encode(want[i])
+	}
+	ds, err := insertRecords(rdb, cfg, &recordSource{in: json.NewDecoder(&buf)}, checkRecords)
+	defer deleteInsertedKeys(rdb, ds.distinct)
+	if err != nil {
+		return err
+	}
+
+	strategies := []struct {
+		name  string
+		fetch func(rdb redis.UniversalClient, ds dataset) ([]fetchedRecord, error)
+	}{
+		{"direct", checkDirect},
+		{"pipeline", checkPipeline},
+		{"lua", checkLua},
+	}
+	var first []fetchedRecord
+	mismatches := 0
+	for _, s := range strategies {
+		got, err := s.fetch(rdb, ds)
+		if err != nil {
+			return err
+		}
+		for i, rec := range want {
+			dec, err := decodeStored(cfg, []byte(got[i].json))
+			if err != nil {
+				fmt.Printf("❌ %s: %s = %q does not decode: %v\n", s.name, ds.jsonKeys[i], got[i].json, err)
+				mismatches++
+			} else if diff := recordDiff(dec, rec); len(diff) > 0 || got[i].email != rec.Email {
+				if got[i].email != rec.Email {
+					diff = append(diff, fmt.Sprintf("hash email %q, want %q", got[i].email, rec.Email))
+				}
+				fmt.Printf("❌ %s: %s: %s\n", s.name, ds.jsonKeys[i], strings.Join(diff, "; "))
+				mismatches++
+			} else if first != nil && got[i] != first[i] {
+				fmt.Printf("❌ %s: %s differs from %s\n", s.name, ds.jsonKeys[i], strategies[0].name)
+				mismatches++
+			}
+		}
+		if first == nil {
+			first = got
+		}
+	}
+	//    and the hash, which HGETALL must rebuild into the same Record,
+	//    amount included bit for bit
+	for i, rec := range want {
+		fields, err := rdb.HGetAll(ctx, ds.hashKeys[i]).Result()
+		if err != nil {
+			return &FetchError{Strategy: "hgetall", Key: ds.hashKeys[i], Err: err}
+		}
+		got, err := recordFromHash(ds.hashKeys[i], fields)
+		if err == nil && got != rec {
+			err = fmt.Errorf("%s", strings.Join(recordDiff(got, rec), "; "))
+		}
+		if err != nil {
+			fmt.Printf("❌ hgetall: %s: %v\n", ds.hashKeys[i], err)
+			mismatches++
+		}
+	}
+	if mismatches > 0 {
+		return fmt.Errorf("%d mismatched values across %d strategies", mismatches, len(strategies))
+	}
+	fmt.Printf("✅ Check passed: %d records identical across %d strategies\n", checkRecords, len(strategies))
+	return nil
+}
+
+// checkDirect reads each record with its own GET and HGET.
+func checkDirect(rdb redis.UniversalClient, ds dataset) ([]fetchedRecord, error) {
+	out := make([]fetchedRecord, len(ds.jsonKeys))
+	for i := range ds.jsonKeys {
+		v, err := rdb.Get(ctx, ds.jsonKeys[i]).Result()
+		if err != nil {
+			return nil, &FetchError{Strategy: "direct", Key: ds.jsonKeys[i], Err: err}
+		}
+		e, err := rdb.HGet(ctx, ds.hashKeys[i], "email").Result()
+		if err != nil {
+			return nil, &FetchError{Strategy: "direct", Key: ds.hashKeys[i], Err: err}
+		}
+		out[i] = fetchedRecord{json: v, email: e}
+	}
+	return out, nil
+}
+
+// checkPipeline reads every record in one pipelined round-trip.
+func checkPipeline(rdb redis.UniversalClient, ds dataset) ([]fetchedRecord, error) {
+	pipe := rdb.Pipeline()
+	gets := make([]*redis.StringCmd, len(ds.jsonKeys))
+	hgets := make([]*redis.StringCmd, len(ds.jsonKeys))
+	for i := range ds.jsonKeys {
+		gets[i] = pipe.Get(ctx, ds.jsonKeys[i])
+		hgets[i] = pipe.HGet(ctx, ds.hashKeys[i], "email")
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, &FetchError{Strategy: "pipeline", Err: err}
+	}
+	out := make([]fetchedRecord, len(ds.jsonKeys))
+	for i := range out {
+		out[i] = fetchedRecord{json: gets[i].Val(), email: hgets[i].Val()}
+	}
+	return out, nil
+}
+
+// checkLua reads every record through runFetchScript. Every record was
+// inserted, so a nil value or email in the reply is an error.
+func checkLua(rdb redis.UniversalClient, ds dataset) ([]fetchedRecord, error) {
+	res, err := runFetchScript(ctx, rdb, ds.jsonKeys, ds.hashKeys)
+	if err != nil {
+		return nil, &FetchError{Strategy: "lua", Err: err}
+	}
+	if err := checkFetchReply(res, ds.jsonKeys, ds.hashKeys); err != nil {
+		return nil, &FetchError{Strategy: "lua", Err: err}
+	}
+	out := make([]fetchedRecord, len(res))
+	for i, r := range res {
+		pair, _ := r.([]interface{})
+		if len(pair) > 0 {
+			out[i].json, _ = pair[0].(string)
+		}
+		if len(pair) > 1 {
+			out[i].email, _ = pair[1].(string)
+		}
+	}
+	return out, nil
+}
+
+// recordDiff lists the fields in which got differs from want.
+func recordDiff(got, want Record) []string {
+	var diff []string
+	if got.ID != want.ID {
+		diff = append(diff, fmt.Sprintf("id %q, want %q", got.ID, want.ID))
+	}
+	if got.Name != want.Name {
+		diff = append(diff, fmt.Sprintf("name %q, want %q", got.Name, want.Name))
+	}
+	if got.Email != want.Email {
+		diff = append(diff, fmt.Sprintf("email %q, want %q", got.Email, want.Email))
+	}
+	if got.Amount != want.Amount {
+		diff = append(diff, fmt.Sprintf("amount %v, want %v", got.Amount, want.Amount))
+	}
+	return diff
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestFetchScriptRejectsMismatchedArgs(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ds := insertTestRecords(t, rdb, 3)
+	err := fetchScript.Run(ctx, rdb, ds.jsonKeys, ds.hashKeys[:2]).Err()
+	if err == nil || !strings.Contains(err.Error(), "3 KEYS but 2 ARGV") {
+		t.Errorf("fetchScript with 3 KEYS and 2 ARGV: %v, want a KEYS/ARGV error", err)
+	}
+}
+
+func TestLuaFetchFailsOnMissingKeys(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ds := insertTestRecords(t, rdb, 10)
+
+	// Swapped-in misses are expected to come back nil
+	fetch, _ := ds.withMisses(0.5)
+	if _, err := luaFetch(false)(ctx, rdb, fetch.jsonKeys, fetch.hashKeys); err != nil {
+		t.Errorf("lua fetch with misses: %v", err)
+	}
+
+	// but an inserted key that is gone is a population bug, unless it may
+	// have expired
+	for _, pick := range []func(dataset) string{
+		func(ds dataset) string { return ds.jsonKeys[3] },
+		func(ds dataset) string { return ds.hashKeys[7] },
+	} {
+		ds := insertTestRecords(t, rdb, 10)
+		key := pick(ds)
+		if err := rdb.Del(ctx, key).Err(); err != nil {
+			t.Fatal(err)
+		}
+		_, err := luaFetch(false)(ctx, rdb, ds.jsonKeys, ds.hashKeys)
+		var fe *FetchError
+		if !errors.As(err, &fe) || !strings.Contains(err.Error(), key) {
+			t.Errorf("lua fetch without %s: %v, want an error naming it", key, err)
+		}
+		if _, err := luaFetch(true)(ctx, rdb, ds.jsonKeys, ds.hashKeys); err != nil {
+			t.Errorf("expiring lua fetch without %s: %v", key, err)
+		}
+		if _, err := checkLua(rdb, ds); err == nil {
+			t.Errorf("checkLua without %s succeeded", key)
+		}
+	}
+}
+
+func TestRecordDiff(t *testing.T) {
+	want := Record{ID: "a", Name: "n", Email: "e", Amount: 1.5}
+	if diff := recordDiff(want, want); len(diff) != 0 {
+		t.Errorf("recordDiff of equal records = %v", diff)
+	}
+	got := want
+	got.Name, got.Amount = "m", 2
+	diff := recordDiff(got, want)
+	if len(diff) != 2 || !strings.HasPrefix(diff[0], "name") || !strings.HasPrefix(diff[1], "amount") {
+		t.Errorf("recordDiff = %v, want name and amount", diff)
+	}
+}
+
+func TestCheckReportsFieldMismatch(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	// Every HSET stores a different email than the JSON record holds
+	rdb.AddHook(rewriteEmailHook{})
+	var err error
+	out := captureStdout(t, func() { err = runCheck(rdb, Config{}) })
+	if err == nil {
+		t.Fatal("runCheck passed with rewritten emails")
+	}
+	if !strings.Contains(out, `hash email "rewritten@example.com"`) {
+		t.Errorf("output does not name the mismatched field:\n%s", out)
+	}
+}
+
+// rewriteEmailHook replaces the email of every HSET sent, so the hashes no
+// longer match the records they were written from.
+type rewriteEmailHook struct{}
+
+func (rewriteEmailHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	args := cmd.Args()
+	for i := 2; cmd.Name() == "hset" && i+1 < len(args); i += 2 {
+		if args[i] == "email" {
+			args[i+1] = "rewritten@example.com"
+		}
+	}
+	return ctx, nil
+}
+
+func (rewriteEmailHook) AfterProcess(context.Context, redis.Cmder) error { return nil }
+
+func (rewriteEmailHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (rewriteEmailHook) AfterProcessPipeline(context.Context, []redis.Cmder) error { return nil }
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"       // for the dialer signature
+	"crypto/tls"    // for -tls connections
+	"crypto/x509"   // for the -tls-ca pool
+	"fmt"           // for formatted I/O
+	"net"           // for the TCP dialer
+	"os"            // for reading -tls-ca
+	"runtime/debug" // for the linked go-redis version
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// newClient connects to the benchmark server at -addr and selects logical
+// DB db, or with -cluster to the cluster reachable through the -addrs seed
+// nodes. go-redis only picks a cluster client for more than one address,
+// so -cluster asks for one explicitly.
+func newClient(cfg Config, db int) redis.UniversalClient {
+	opt := universalOptions(cfg, db)
+	if cfg.Cluster {
+		return redis.NewClusterClient(opt.Cluster())
+	}
+	return redis.NewUniversalClient(opt)
+}
+
+// universalOptions is clientOptions for newClient: the same settings, with
+// the -addrs seed nodes as the addresses under -cluster.
+func universalOptions(cfg Config, db int) *redis.UniversalOptions {
+	opt := clientOptions(cfg, cfg.Addr, db)
+	addrs := []string{opt.Addr}
+	if cfg.Cluster {
+		addrs = cfg.Addrs
+	}
+	return &redis.UniversalOptions{
+		Addrs:        addrs,
+		DB:           opt.DB,
+		Password:     opt.Password,
+		Dialer:       opt.Dialer,
+		OnConnect:    opt.OnConnect,
+		DialTimeout:  opt.DialTimeout,
+		ReadTimeout:  opt.ReadTimeout,
+		WriteTimeout: opt.WriteTimeout,
+		TLSConfig:    opt.TLSConfig,
+		PoolSize:     opt.PoolSize,
+	}
+}
+
+// clientOptions builds the connection options for addr and logical DB db,
+// applying the password, timeout, keepalive and pool size options from
+// cfg.
+func clientOptions(cfg Config, addr string, db int) *redis.Options {
+	opt := &redis.Options{
+		Addr:         addr,
+		DB:           db,
+		Password:     cfg.Password,
+		Dialer:       newDialer(cfg),
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		TLSConfig:    cfg.TLSConfig,
+		PoolSize:     cfg.PoolSize,
+	}
+	if cfg.ClientName != "" {
+		opt.OnConnect = setNameOnConnect(cfg.ClientName)
+	}
+	return opt
+}
+
+// setNameOnConnect returns an OnConnect hook that tags every new
+// connection with CLIENT SETNAME name, so it shows up in CLIENT LIST.
+func setNameOnConnect(name string) func(ctx context.Context, cn *redis.Conn) error {
+	return func(ctx context.Context, cn *redis.Conn) error {
+		return cn.ClientSetName(ctx, name).Err()
+	}
+}
+
+// newDialer returns a TCP dialer honouring -dial-timeout, -keepalive and
+// -tcp-nodelay. Go enables TCP_NODELAY on every TCP connection by default,
+// so only turning it off changes anything. go-redis ignores TLSConfig once
+// a Dialer is set, so with -tls the dialer also runs the TLS handshake.
+func newDialer(cfg Config) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := d.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		if tc, ok := conn.(*net.TCPConn); ok {
+			if err := tc.SetNoDelay(cfg.NoDelay); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("set TCP_NODELAY: %w", err)
+			}
+		}
+		if cfg.TLSConfig == nil {
+			return conn, nil
+		}
+		//    Verify the certificate against the host dialed, as
+		//    tls.Dial does
+		tc := cfg.TLSConfig
+		if tc.ServerName == "" {
+			tc = tc.Clone()
+			tc.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		tlsConn := tls.Client(conn, tc)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS handshake with %s: %w", addr, err)
+		}
+		return tlsConn, nil
+	}
+}
+
+// loadTLSConfig builds the -tls client config: the -tls-cert/-tls-key
+// pair as the client certificate and -tls-ca as the only trusted roots,
+// when given. Unreadable or invalid files are reported here, before the
+// first connection, rather than as a handshake failure.
+func loadTLSConfig(cfg Config) (*tls.Config, error) {
+	tc := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.TLSSkipVerify,
+	}
+	if cfg.TLSCert != "" {
+		pair, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading -tls-cert %s / -tls-key %s: %w", cfg.TLSCert, cfg.TLSKey, err)
+		}
+		tc.Certificates = []tls.Certificate{pair}
+	}
+	if cfg.TLSCA != "" {
+		pem, err := os.ReadFile(cfg.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("reading -tls-ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("-tls-ca %s holds no PEM certificates", cfg.TLSCA)
+		}
+		tc.RootCAs = pool
+	}
+	return tc, nil
+}
+
+// printPoolStats reports connection churn and timeouts seen by the pool,
+// which is where overly tight timeouts or dropped idle connections show up.
+func printPoolStats(rdb redis.UniversalClient) {
+	st := rdb.PoolStats()
+	fmt.Printf("📶 Pool: %d conns opened, %d timeouts, %d stale conns dropped\n",
+		st.Misses, st.Timeouts, st.StaleConns)
+}
+
+// clientModule is the module path of the Redis client library.
+const clientModule = "github.com/go-redis/redis/v8"
+
+// clientVersion returns the go-redis module version linked into this
+// binary, following any replace directive, or "unknown" if the binary was
+// built without module information.
+func clientVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range bi.Deps {
+		if dep.Path == clientModule {
+			return moduleVersion(dep)
+		}
+	}
+	return "unknown"
+}
+
+// moduleVersion returns the version of dep, preferring its replacement's.
+// A replacement by a local directory has no version, so it falls back to
+// the version required in go.mod, and then to "(devel)".
+func moduleVersion(dep *debug.Module) string {
+	if dep.Replace != nil && dep.Replace.Version != "" {
+		return dep.Replace.Version
+	}
+	if dep.Version != "" {
+		return dep.Version
+	}
+	return "(devel)"
+}
+
+// gitSHA returns the VCS revision the binary was built from, with a
+// "-dirty" suffix for uncommitted changes, or "unknown" if the build
+// carries no VCS information.
+func gitSHA() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	rev, dirty := "", false
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+		case "vcs.modified":
+			dirty = s.Value == "true"
+		}
+	}
+	if rev == "" {
+		return "unknown"
+	}
+	if dirty {
+		rev += "-dirty"
+	}
+	return rev
+}
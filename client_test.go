@@ -0,0 +1,137 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// writeTestCert writes a self-signed certificate for 127.0.0.1 and its key
+// as PEM files in dir and returns their paths.
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "bench test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestTLSConnection(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir())
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := miniredis.RunTLS(&tls.Config{Certificates: []tls.Certificate{pair}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.Close)
+
+	ping := func(cfg Config) error {
+		rdb := newClient(cfg, 0)
+		defer rdb.Close()
+		return rdb.Ping(ctx).Err()
+	}
+
+	// Trusting the server's own certificate through -tls-ca
+	cfg := Config{Addr: m.Addr(), TLS: true, TLSCA: certFile}
+	if cfg.TLSConfig, err = loadTLSConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := ping(cfg); err != nil {
+		t.Errorf("PING over TLS with -tls-ca: %v", err)
+	}
+
+	// Without it the self-signed certificate is rejected, unless
+	// verification is skipped
+	cfg = Config{Addr: m.Addr(), TLS: true}
+	if cfg.TLSConfig, err = loadTLSConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := ping(cfg); err == nil || !strings.Contains(err.Error(), "TLS handshake") {
+		t.Errorf("PING with an untrusted certificate: got %v, want a TLS handshake error", err)
+	}
+	cfg.TLSSkipVerify = true
+	if cfg.TLSConfig, err = loadTLSConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := ping(cfg); err != nil {
+		t.Errorf("PING over TLS with -tls-skip-verify: %v", err)
+	}
+}
+
+func TestLoadTLSConfigUnreadableFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+	missing := filepath.Join(dir, "missing.pem")
+	for _, cfg := range []Config{
+		{TLS: true, TLSCert: missing, TLSKey: keyFile},
+		{TLS: true, TLSCert: certFile, TLSKey: missing},
+		{TLS: true, TLSCA: missing},
+		{TLS: true, TLSCA: keyFile}, // no certificate in it
+	} {
+		if _, err := loadTLSConfig(cfg); err == nil {
+			t.Errorf("loadTLSConfig(%+v) succeeded, want an error", cfg)
+		} else if !strings.Contains(err.Error(), "-tls-") {
+			t.Errorf("loadTLSConfig error %q does not name the flag", err)
+		}
+	}
+}
+
+func TestModuleVersion(t *testing.T) {
+	for _, tt := range []struct {
+		dep  debug.Module
+		want string
+	}{
+		{debug.Module{Version: "v8.11.5"}, "v8.11.5"},
+		{debug.Module{Version: "v8.11.5", Replace: &debug.Module{Path: "example.com/fork", Version: "v8.11.6"}}, "v8.11.6"},
+		{debug.Module{Version: "v8.11.5", Replace: &debug.Module{Path: "../redis"}}, "v8.11.5"},
+		{debug.Module{Replace: &debug.Module{Path: "../redis"}}, "(devel)"},
+	} {
+		if got := moduleVersion(&tt.dep); got != tt.want {
+			t.Errorf("moduleVersion(%+v) = %q, want %q", tt.dep, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"  // for formatted I/O
+	"time" // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// clientNameDials is how many fresh connections each side of the
+// connection-naming comparison opens.
+const clientNameDials = 100
+
+// runClientNameBench measures what CLIENT SETNAME in OnConnect costs. The
+// hook runs once per new connection, so the comparison opens fresh
+// connections with and without it, then checks that steady-state commands
+// on an already named connection are unaffected.
+func runClientNameBench(rdb redis.UniversalClient, cfg Config) error {
+	name := cfg.ClientName
+	if name == "" {
+		name = "redis-bench"
+	}
+	plain := cfg
+	plain.ClientName = ""
+	named := cfg
+	named.ClientName = name
+
+	fmt.Printf("CLIENT SETNAME %q in OnConnect\n", name)
+	fmt.Println("Connection | New conn + PING | PING (warm)")
+	fmt.Println("-----------+----------------+------------")
+	var dial [2]time.Duration
+	for i, c := range []Config{plain, named} {
+		d, err := timeFreshConns(c)
+		if err != nil {
+			return err
+		}
+		warm, err := timeWarmPings(c, cfg.WorkloadSize)
+		if err != nil {
+			return err
+		}
+		dial[i] = d
+		label := "unnamed"
+		if c.ClientName != "" {
+			label = "named"
+		}
+		fmt.Printf("%-10s | %14v | %v\n", label, d, warm)
+	}
+
+	// Verify the hook really tagged the connection
+	c := newClient(named, cfg.DB)
+	defer c.Close()
+	got, err := c.ClientGetName(ctx).Result()
+	if err != nil {
+		return fmt.Errorf("CLIENT GETNAME failed: %w", err)
+	}
+	if got != name {
+		return fmt.Errorf("CLIENT GETNAME = %q, want %q", got, name)
+	}
+	fmt.Printf("  naming adds %v per new connection; CLIENT GETNAME confirms %q\n",
+		dial[1]-dial[0], got)
+	return nil
+}
+
+// timeFreshConns returns the mean time to open a brand-new client
+// connection and run one PING on it.
+func timeFreshConns(cfg Config) (time.Duration, error) {
+	var total time.Duration
+	for i := 0; i < clientNameDials; i++ {
+		c := newClient(cfg, cfg.DB)
+		t0 := time.Now()
+		err := c.Ping(ctx).Err()
+		total += time.Since(t0)
+		c.Close()
+		if err != nil {
+			return 0, fmt.Errorf("PING on new connection failed: %w", err)
+		}
+	}
+	return total / clientNameDials, nil
+}
+
+// timeWarmPings returns the mean PING latency on an already open
+// connection.
+func timeWarmPings(cfg Config, n int) (time.Duration, error) {
+	c := newClient(cfg, cfg.DB)
+	defer c.Close()
+	if err := c.Ping(ctx).Err(); err != nil {
+		return 0, fmt.Errorf("PING failed: %w", err)
+	}
+	t0 := time.Now()
+	for i := 0; i < n; i++ {
+		if err := c.Ping(ctx).Err(); err != nil {
+			return 0, fmt.Errorf("PING failed: %w", err)
+		}
+	}
+	return time.Since(t0) / time.Duration(n), nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context" // for the per-node callback signature
+	"fmt"     // for formatted errors
+	"strings" // for hash tags and NOSCRIPT replies
+	"sync"    // for serialising per-node callbacks
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// With -cluster, rdb is a *redis.ClusterClient. go-redis routes each
+// command by the slot of its first key, so a multi-key command, an EVAL
+// whose KEYS span slots, or a key-less command like FLUSHDB or INFO that
+// must reach every node needs the helpers below. Each one behaves exactly
+// as before against a standalone server.
+
+// isCluster reports whether rdb talks to a Redis Cluster.
+func isCluster(rdb redis.UniversalClient) bool {
+	_, ok := rdb.(*redis.ClusterClient)
+	return ok
+}
+
+// forEachMaster calls fn with a client for every master: each cluster
+// master in turn, or rdb itself against a standalone server. go-redis
+// visits the masters concurrently, so fn calls are serialised here and may
+// share state without locking.
+func forEachMaster(rdb redis.UniversalClient, fn func(*redis.Client) error) error {
+	switch c := rdb.(type) {
+	case *redis.ClusterClient:
+		var mu sync.Mutex
+		return c.ForEachMaster(ctx, func(_ context.Context, node *redis.Client) error {
+			mu.Lock()
+			defer mu.Unlock()
+			return fn(node)
+		})
+	case *redis.Client:
+		return fn(c)
+	default:
+		return fmt.Errorf("unsupported client type %T", rdb)
+	}
+}
+
+// keySlot returns the cluster hash slot of key: CRC16 (XMODEM) of the key,
+// or of its {hash tag} when it has a non-empty one, modulo clusterSlots.
+func keySlot(key string) int {
+	if i := strings.IndexByte(key, '{'); i >= 0 {
+		if j := strings.IndexByte(key[i+1:], '}'); j > 0 {
+			key = key[i+1 : i+1+j]
+		}
+	}
+	var crc uint16
+	for k := 0; k < len(key); k++ {
+		crc ^= uint16(key[k]) << 8
+		for b := 0; b < 8; b++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return int(crc) % clusterSlots
+}
+
+// slotGroups splits the indexes of keys by hash slot, in order of each
+// slot's first key, keeping the keys' order within a group.
+func slotGroups(keys []string) [][]int {
+	bySlot := make(map[int]int)
+	var groups [][]int
+	for i, key := range keys {
+		slot := keySlot(key)
+		g, ok := bySlot[slot]
+		if !ok {
+			g = len(groups)
+			bySlot[slot] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// pick returns keys[i] for every i in idx.
+func pick(keys []string, idx []int) []string {
+	out := make([]string, len(idx))
+	for j, i := range idx {
+		out[j] = keys[i]
+	}
+	return out
+}
+
+// countBySlot runs cmd, a multi-key command with an integer reply such as
+// DEL or EXISTS, once per hash slot of keys in one pipeline and returns
+// the sum of the replies.
+func countBySlot(rdb redis.UniversalClient, keys []string, cmd func(redis.Pipeliner, context.Context, ...string) *redis.IntCmd) (int64, error) {
+	pipe := rdb.Pipeline()
+	var cmds []*redis.IntCmd
+	for _, g := range slotGroups(keys) {
+		cmds = append(cmds, cmd(pipe, ctx, pick(keys, g)...))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, c := range cmds {
+		n += c.Val()
+	}
+	return n, nil
+}
+
+// mgetBySlot is MGET that, against a cluster, pipelines one MGET per hash
+// slot of keys and returns the values in the order of keys.
+func mgetBySlot(ctx context.Context, rdb redis.UniversalClient, keys []string) ([]interface{}, error) {
+	if !isCluster(rdb) {
+		return rdb.MGet(ctx, keys...).Result()
+	}
+	groups := slotGroups(keys)
+	pipe := rdb.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(groups))
+	for i, g := range groups {
+		cmds[i] = pipe.MGet(ctx, pick(keys, g)...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	vals := make([]interface{}, len(keys))
+	for i, g := range groups {
+		got := cmds[i].Val()
+		if len(got) != len(g) {
+			return nil, fmt.Errorf("MGET returned %d values for %d keys", len(got), len(g))
+		}
+		for j, k := range g {
+			vals[k] = got[j]
+		}
+	}
+	return vals, nil
+}
+
+// evalBySlot runs script once per entry of keys, with the matching entry
+// of args as ARGV, in one pipeline; the KEYS of each call must share a
+// slot, so go-redis sends it to the master that owns them. EVALSHA needs
+// the script cached on every master, so on NOSCRIPT it is loaded on all of
+// them and the calls are retried once.
+func evalBySlot(ctx context.Context, rdb redis.UniversalClient, script *redis.Script, keys [][]string, args [][]interface{}) ([]*redis.Cmd, error) {
+	run := func() ([]*redis.Cmd, error) {
+		pipe := rdb.Pipeline()
+		cmds := make([]*redis.Cmd, len(keys))
+		for i := range keys {
+			cmds[i] = script.EvalSha(ctx, pipe, keys[i], args[i]...)
+		}
+		_, err := pipe.Exec(ctx)
+		return cmds, err
+	}
+	cmds, err := run()
+	if err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		if err := script.Load(ctx, rdb).Err(); err != nil {
+			return nil, err
+		}
+		cmds, err = run()
+	}
+	return cmds, err
+}
+
+// runFetchScript runs fetchScript over every record and returns its
+// {value, email} reply per record, in order. A single EVAL cannot touch
+// keys in several slots, so against a cluster the records are split by
+// slot, and with the random IDs of generated records that is close to one
+// EVALSHA per record: the lua column then measures pipelined per-record
+// scripts rather than one server-side call.
+func runFetchScript(ctx context.Context, rdb redis.UniversalClient, jsonKeys, hashKeys []string) ([]interface{}, error) {
+	if !isCluster(rdb) {
+		return fetchScript.Run(ctx, rdb, jsonKeys, hashKeys).Slice()
+	}
+	groups := slotGroups(jsonKeys)
+	keys := make([][]string, len(groups))
+	args := make([][]interface{}, len(groups))
+	for i, g := range groups {
+		keys[i] = pick(jsonKeys, g)
+		args[i] = []interface{}{pick(hashKeys, g)}
+	}
+	cmds, err := evalBySlot(ctx, rdb, fetchScript, keys, args)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, len(jsonKeys))
+	for i, g := range groups {
+		res, err := cmds[i].Slice()
+		if err != nil {
+			return nil, err
+		}
+		if len(res) != len(g) {
+			return nil, fmt.Errorf("script returned %d records for %d keys", len(res), len(g))
+		}
+		for j, k := range g {
+			out[k] = res[j]
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// newTestCluster starts a miniredis server, which answers CLUSTER SLOTS
+// as a single master owning every slot, and returns it with a cluster
+// client seeded from it. It does not enforce CROSSSLOT, so tests check the
+// commands sent instead.
+func newTestCluster(t *testing.T) (*miniredis.Miniredis, redis.UniversalClient) {
+	t.Helper()
+	m := miniredis.RunT(t)
+	rdb := newClient(Config{Cluster: true, Addrs: []string{m.Addr()}}, 0)
+	t.Cleanup(func() { rdb.Close() })
+	return m, rdb
+}
+
+func TestKeySlot(t *testing.T) {
+	for _, tt := range []struct {
+		key  string
+		want int
+	}{
+		{"foo", 12182},
+		{"123456789", 12739},    // CRC16/XMODEM check value 0x31C3
+		{"{foo}.bar", 12182},    // hash tag
+		{"a{foo}b{bar}", 12182}, // only the first tag counts
+	} {
+		if got := keySlot(tt.key); got != tt.want {
+			t.Errorf("keySlot(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+	if keySlot("{}foo") == keySlot("foo") {
+		t.Error(`keySlot("{}foo") hashed "foo" instead of ignoring the empty tag`)
+	}
+
+	jsonKey, hashKey := recordKeys("0b6a2c1e-4c4e-4d8e-9a4d-2f1c2b3a4d5e")
+	if keySlot(jsonKey) != keySlot(hashKey) {
+		t.Errorf("record keys %s and %s hash to different slots", jsonKey, hashKey)
+	}
+}
+
+func TestSlotGroups(t *testing.T) {
+	keys := []string{"{a}1", "{b}1", "{a}2", "{c}1", "{b}2"}
+	got := slotGroups(keys)
+	want := [][]int{{0, 2}, {1, 4}, {3}}
+	if len(got) != len(want) {
+		t.Fatalf("slotGroups = %v, want %v", got, want)
+	}
+	for i := range want {
+		if len(got[i]) != len(want[i]) {
+			t.Fatalf("slotGroups = %v, want %v", got, want)
+		}
+		for j := range want[i] {
+			if got[i][j] != want[i][j] {
+				t.Fatalf("slotGroups = %v, want %v", got, want)
+			}
+		}
+	}
+}
+
+func TestClusterStrategiesSplitBySlot(t *testing.T) {
+	m, rdb := newTestCluster(t)
+	const n = 20
+	ds := insertTestRecords(t, rdb, n)
+	groups := len(slotGroups(ds.jsonKeys))
+
+	// The script and MGET strategies send one command per slot. The
+	// first writeLua also loads the script, so count the second, less
+	// the SET and HSET per record that miniredis counts inside the script
+	for pass := 0; pass < 2; pass++ {
+		before := m.CommandCount()
+		if _, err := writeLua(ctx, rdb, ds); err != nil {
+			t.Fatalf("writeLua: %v", err)
+		}
+		if got := m.CommandCount() - before - 2*n; pass == 1 && got != groups {
+			t.Errorf("writeLua sent %d script calls for %d slots", got, groups)
+		}
+	}
+	before := m.CommandCount()
+	if _, err := batchFetch(false)(ctx, rdb, ds.jsonKeys, ds.hashKeys); err != nil {
+		t.Fatalf("batch fetch: %v", err)
+	}
+	if got, want := m.CommandCount()-before, groups+n; got != want {
+		t.Errorf("batch fetch sent %d commands, want %d MGETs and %d HGETs", got, groups, n)
+	}
+
+	// and their replies come back in record order
+	res, err := runFetchScript(ctx, rdb, ds.jsonKeys, ds.hashKeys)
+	if err != nil {
+		t.Fatalf("runFetchScript: %v", err)
+	}
+	for i, r := range res {
+		pair, _ := r.([]interface{})
+		if len(pair) != 2 || pair[0] != ds.values[i] || pair[1] != ds.emails[i] {
+			t.Fatalf("record %d: got %v, want {%s, %s}", i, r, ds.values[i], ds.emails[i])
+		}
+	}
+
+	// Cleanup reaches every key on every master
+	deleted, err := deleteKeys(rdb, ds.distinct)
+	if err != nil {
+		t.Fatalf("deleteKeys: %v", err)
+	}
+	if deleted != int64(2*n) {
+		t.Errorf("deleteKeys removed %d keys, want %d", deleted, 2*n)
+	}
+	if keys := m.Keys(); len(keys) != 0 {
+		t.Errorf("%d keys left after cleanup", len(keys))
+	}
+}
+
+func TestClusterUnsafeWorkload(t *testing.T) {
+	if got := clusterUnsafeWorkload(Config{GetEx: true, Counters: true}); got != "" {
+		t.Errorf("getex and counters reported %q as cluster-unsafe", got)
+	}
+	if got := clusterUnsafeWorkload(Config{GetEx: true, MGetSweep: true}); got != "mget-sweep" {
+		t.Errorf("clusterUnsafeWorkload = %q, want mget-sweep", got)
+	}
+	names := map[string]bool{}
+	for _, w := range workloads(Config{}) {
+		names[w.name] = true
+	}
+	for name := range singleNodeWorkloads {
+		if !names[name] {
+			t.Errorf("singleNodeWorkloads names %q, which is not a workload", name)
+		}
+	}
+}
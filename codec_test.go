@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestEncodingsRoundTripAmount(t *testing.T) {
+	amounts := []float64{
+		0, math.Copysign(0, -1), 0.1, 1.0 / 3, 12345.678, -99999,
+		math.SmallestNonzeroFloat64, math.MaxFloat64, math.Nextafter(1, 2),
+	}
+	for _, encoding := range []string{"json", "gob", "msgpack"} {
+		for _, meta := range []map[string]string{nil, defaultEnvelope} {
+			for _, amount := range amounts {
+				rec := Record{ID: "id", Name: "name", Email: "e@example.com", Amount: amount}
+				data, err := encodeRecord(encoding, meta, rec)
+				if err != nil {
+					t.Fatalf("%s: encode: %v", encoding, err)
+				}
+				got, err := decodeRecord(encoding, meta, data)
+				if err != nil {
+					t.Fatalf("%s: decode: %v", encoding, err)
+				}
+				same := math.Float64bits(got.Amount) == math.Float64bits(amount)
+				if encoding == "gob" && amount == 0 {
+					same = got.Amount == 0 // gob drops the sign of -0, see marshalValue
+				}
+				if !same || got.ID != rec.ID || got.Email != rec.Email {
+					t.Errorf("%s (envelope %v): %+v came back as %+v", encoding, meta != nil, rec, got)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodingFetchUsesEncoding(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	src, err := openRecordSource(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 5
+	ds, err := insertRecords(rdb, Config{Encoding: "msgpack"}, src, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := decodingFetch(Config{Encoding: "msgpack"})(ctx, rdb, ds.jsonKeys, ds.hashKeys); err != nil {
+		t.Errorf("msgpack records failed to decode: %v", err)
+	}
+	// Decoding them as gob fails every record, without stopping the fetch
+	_, err = decodingFetch(Config{Encoding: "gob"})(ctx, rdb, ds.jsonKeys, ds.hashKeys)
+	var partial *PartialFetchError
+	if !errors.As(err, &partial) || partial.Failed != n {
+		t.Errorf("decoding msgpack as gob: got %v, want all %d records failed", err, n)
+	}
+}
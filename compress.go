@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"         // for gzip buffers
+	"compress/gzip" // for -compress
+	"fmt"           // for formatted I/O
+	"io"            // for reading decompressed values
+	"time"          // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// compressValue gzips data. A small record comes out larger than it went
+// in, since gzip adds an 18-byte header and trailer; it still round-trips.
+func compressValue(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressValue is the inverse of compressValue.
+func decompressValue(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// encodeStored returns the value insertRecords stores under a record's
+// JSON key: encodeRecord's output, gzipped with -compress. The hash key
+// holds plain fields either way.
+func encodeStored(cfg Config, rec Record) ([]byte, error) {
+	data, err := encodeRecord(cfg.Encoding, cfg.Envelope, rec)
+	if err != nil || !cfg.Compress {
+		return data, err
+	}
+	return compressValue(data)
+}
+
+// decodeStored is the inverse of encodeStored.
+func decodeStored(cfg Config, data []byte) (Record, error) {
+	if cfg.Compress {
+		var err error
+		if data, err = decompressValue(data); err != nil {
+			return Record{}, fmt.Errorf("gunzip: %w", err)
+		}
+	}
+	return decodeRecord(cfg.Encoding, cfg.Envelope, data)
+}
+
+// storedEncoding names how records are stored under the JSON key, such
+// as "json" or, with -compress, "json+gzip".
+func storedEncoding(cfg Config) string {
+	if cfg.Compress {
+		return cfg.Encoding + "+gzip"
+	}
+	return cfg.Encoding
+}
+
+// runCompressCompare stores -workload-size records as plain and then as
+// gzipped values under -encoding, reporting the memory, value size,
+// insert and fetch+decode cost of each, and verifies every record
+// round-trips.
+func runCompressCompare(rdb redis.UniversalClient, cfg Config) error {
+	n := cfg.WorkloadSize
+	recs := make([]Record, n)
+	for i := range recs {
+		recs[i] = generateRecord()
+	}
+
+	fmt.Printf("Value compression (%d records, %s)\n", n, cfg.Encoding)
+	fmt.Println("Storage   | ΔMem (MB) | Value B/rec | Insert       | Fetch+decode")
+	fmt.Println("----------+-----------+-------------+--------------+-------------")
+	for _, shape := range []struct {
+		name     string
+		prefix   string
+		compress bool
+	}{
+		{"plain", keyPrefix + "gz:plain:", false},
+		{"gzip", keyPrefix + "gz:gzip:", true},
+	} {
+		scfg := cfg
+		scfg.Compress = shape.compress
+		row, err := measureCompressShape(rdb, scfg, shape.prefix, recs)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%-9s | %+9.2f | %11.1f | %12v | %v\n", shape.name,
+			float64(row.memDelta)/1024.0/1024.0, float64(row.valueBytes)/float64(n), row.insert, row.fetch)
+	}
+	fmt.Println("  every compressed record round-tripped intact")
+	return nil
+}
+
+// measureCompressShape stores recs under prefix as cfg encodes them, reads
+// each back and checks it round-trips, and deletes the keys before
+// returning.
+func measureCompressShape(rdb redis.UniversalClient, cfg Config, prefix string, recs []Record) (shapeRow, error) {
+	var row shapeRow
+	keys := make([]string, len(recs))
+	for i, rec := range recs {
+		keys[i] = prefix + rec.ID
+	}
+	defer deleteInsertedKeys(rdb, keys)
+
+	before, err := getMemory(rdb)
+	if err != nil {
+		return row, err
+	}
+	t0 := time.Now()
+	for i, rec := range recs {
+		data, err := encodeStored(cfg, rec)
+		if err != nil {
+			return row, err
+		}
+		row.valueBytes += len(data)
+		if err := rdb.Set(ctx, keys[i], data, 0).Err(); err != nil {
+			return row, &InsertError{Key: keys[i], Phase: "SET", Err: err}
+		}
+	}
+	row.insert = time.Since(t0)
+	after, err := getMemory(rdb)
+	if err != nil {
+		return row, err
+	}
+	row.memDelta = after - before
+
+	t1 := time.Now()
+	for i, key := range keys {
+		data, err := rdb.Get(ctx, key).Bytes()
+		if err != nil {
+			return row, &FetchError{Strategy: "direct", Key: key, Err: err}
+		}
+		got, err := decodeStored(cfg, data)
+		if err == nil && got != recs[i] {
+			err = fmt.Errorf("decoded %+v, want %+v", got, recs[i])
+		}
+		if err != nil {
+			return row, fmt.Errorf("%s does not round-trip: %w", key, err)
+		}
+	}
+	row.fetch = time.Since(t1)
+	return row, nil
+}
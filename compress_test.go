@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	for _, in := range [][]byte{{}, []byte("x"), []byte(`{"id":"a"}`), bytes.Repeat([]byte("abc"), 1000)} {
+		z, err := compressValue(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(in) < 10 && len(z) <= len(in) {
+			t.Errorf("%d bytes compressed to %d; gzip should add overhead", len(in), len(z))
+		}
+		out, err := decompressValue(z)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", len(in), err)
+		}
+		if !bytes.Equal(out, in) {
+			t.Errorf("round trip of %d bytes gave %q", len(in), out)
+		}
+	}
+	if _, err := decompressValue([]byte(`{"id":"a"}`)); err == nil {
+		t.Error("decompressing plain JSON succeeded")
+	}
+}
+
+func TestEncodeStoredCompress(t *testing.T) {
+	rec := generateRecord()
+	for _, enc := range []string{"json", "gob", "msgpack"} {
+		cfg := Config{Encoding: enc, Compress: true}
+		data, err := encodeStored(cfg, rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+			t.Errorf("%s: stored value is not gzip: % x", enc, data[:2])
+		}
+		got, err := decodeStored(cfg, data)
+		if err != nil || got != rec {
+			t.Errorf("%s: decoded %+v (%v), want %+v", enc, got, err, rec)
+		}
+		if storedEncoding(cfg) != enc+"+gzip" {
+			t.Errorf("storedEncoding = %q", storedEncoding(cfg))
+		}
+	}
+}
+
+func TestInsertCompressed(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	cfg := Config{Encoding: "json", Compress: true}
+	src, err := openRecordSource(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, err := insertRecords(rdb, cfg, src, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decodingFetch(cfg)(ctx, rdb, ds.jsonKeys, ds.hashKeys); err != nil {
+		t.Errorf("direct fetch of compressed records: %v", err)
+	}
+
+	// The hash key is unaffected
+	for i, key := range ds.hashKeys {
+		fields, err := rdb.HGetAll(ctx, key).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec, err := recordFromHash(key, fields)
+		if err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		if rec.Email != ds.emails[i] {
+			t.Errorf("%s email %q, want %q", key, rec.Email, ds.emails[i])
+		}
+	}
+
+	// A plain value fails its record when -compress expects gzip
+	if err := rdb.Set(ctx, ds.jsonKeys[0], `{"id":"a"}`, 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	_, err = decodingFetch(cfg)(ctx, rdb, ds.jsonKeys, ds.hashKeys)
+	var partial *PartialFetchError
+	if !errors.As(err, &partial) || partial.Failed != 1 {
+		t.Errorf("fetch with one plain value: %v, want 1 failed record", err)
+	}
+}
+
+func TestCompressCompare(t *testing.T) {
+	m, rdb := newTestRedis(t)
+	var runErr error
+	out := captureStdout(t, func() {
+		runErr = runCompressCompare(rdb, Config{Encoding: "json", WorkloadSize: 20})
+	})
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+	for _, want := range []string{"plain     |", "gzip      |", "round-tripped intact"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if keys := m.Keys(); len(keys) != 0 {
+		t.Errorf("%d keys left after the workload", len(keys))
+	}
+}
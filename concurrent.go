@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"     // for cancelling workers
+	"errors"      // for recognising partial fetches
+	"sync"        // for the worker pool
+	"sync/atomic" // for handing out batches
+	"time"        // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// fetchConcurrent runs fn over the records from workers goroutines. The
+// records are cut into batches of batch records, which workers claim one
+// at a time until none are left, so a slow batch does not hold up the
+// others. It returns the wall-clock time of the whole fetch and the
+// latency of every batch. A *PartialFetchError from fn is added up and
+// the batch still counts; the first other worker error cancels the rest
+// and is returned. Workers never exit the process themselves.
+func fetchConcurrent(ctx context.Context, fn fetchFunc, rdb redis.UniversalClient, jsonKeys, hashKeys []string, workers, batch int) (time.Duration, []time.Duration, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	n := len(jsonKeys)
+	batches := (n + batch - 1) / batch
+	lat := make([]time.Duration, batches)
+	errs := make(chan error, workers)
+	var next, failed int64
+	var first error
+	var firstOnce sync.Once
+	var wg sync.WaitGroup
+
+	t0 := time.Now()
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				b := int(atomic.AddInt64(&next, 1)) - 1
+				if b >= batches || ctx.Err() != nil {
+					return
+				}
+				i, j := b*batch, (b+1)*batch
+				if j > n {
+					j = n
+				}
+				t := time.Now()
+				if _, err := fn(ctx, rdb, jsonKeys[i:j], hashKeys[i:j]); err != nil {
+					var partial *PartialFetchError
+					if !errors.As(err, &partial) {
+						errs <- err
+						cancel()
+						return
+					}
+					atomic.AddInt64(&failed, int64(partial.Failed))
+					firstOnce.Do(func() { first = partial.Err })
+				}
+				lat[b] = time.Since(t)
+			}
+		}()
+	}
+	wg.Wait()
+	dur := time.Since(t0)
+	close(errs)
+	if err := <-errs; err != nil {
+		return 0, nil, err
+	}
+	if failed > 0 {
+		return dur, lat, &PartialFetchError{Strategy: "concurrent", Failed: int(failed), Total: n, Err: first}
+	}
+	return dur, lat, nil
+}
+
+// concurrentFetch returns the concurrent fetch strategy: direct, the
+// per-record direct strategy (decoding like the counted direct column),
+// with the records split across workers goroutines sharing the client's
+// connection pool.
+func concurrentFetch(direct fetchFunc, workers int) fetchFunc {
+	return func(ctx context.Context, rdb redis.UniversalClient, jsonKeys, hashKeys []string) (time.Duration, error) {
+		d, _, err := fetchConcurrent(ctx, direct, rdb, jsonKeys, hashKeys, workers, 1)
+		var partial *PartialFetchError
+		if err != nil && !errors.As(err, &partial) {
+			return 0, &FetchError{Strategy: "concurrent", Err: err}
+		}
+		return d, err
+	}
+}
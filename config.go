@@ -0,0 +1,662 @@
+package main
+
+import (
+	"crypto/tls" // for the -tls client config
+	"flag"       // for command-line options
+	"fmt"        // for option parse errors
+	"log"        // for rejecting invalid options
+	"os"         // for environment fallbacks
+	"runtime"    // for the default worker count
+	"strconv"    // for parsing numeric lists
+	"strings"    // for splitting list options
+	"time"       // for duration-valued options
+
+	"github.com/google/uuid" // for random run IDs
+)
+
+// Config holds the command-line options for a benchmark run.
+type Config struct {
+	Addr     string // host:port of the benchmark server
+	DB       int    // logical DB the benchmark writes to
+	Password string // AUTH password ("" = none)
+	NoFlush  bool   // skip the per-size FLUSHDB and rely on tracked-key cleanup
+
+	ResultsDB     int    // logical DB that stores run history (-1 disables)
+	ResultsStream string // stream key the run history is appended to
+
+	CollisionRate float64 // fraction of inserts that overwrite an earlier record
+
+	WorkloadSize int           // records used by each optional workload
+	GetEx        bool          // run the GETEX vs GET+EXPIRE workload
+	GetExTTL     time.Duration // TTL applied by both GETEX and EXPIRE
+
+	CappedList bool  // run the LPUSH+LTRIM capped-list workload
+	ListCaps   []int // list lengths kept by the capped-list workload
+
+	DialTimeout  time.Duration // connect timeout (0 = go-redis default 5s)
+	ReadTimeout  time.Duration // socket read timeout (0 = go-redis default 3s)
+	WriteTimeout time.Duration // socket write timeout (0 = same as read)
+	KeepAlive    time.Duration // TCP keepalive probe interval (0 = Go default 15s)
+
+	EvalPerKey bool // run the per-key EVALSHA vs GET workload
+
+	DatasetExport string // JSONL file that receives every inserted record
+	DatasetImport string // JSONL file whose records replace generated ones
+
+	PipelineErrors bool // run the pipeline partial-failure workload
+
+	ResetStats   bool // CONFIG RESETSTAT before every size
+	ResetLatency bool // LATENCY RESET before every size
+
+	MaxMemoryMB float64 // abort once used_memory exceeds this after an insert (0 = off)
+
+	RandomAccess bool // run the HRANDFIELD / SRANDMEMBER workload
+	RandomCount  int  // sample size requested per random-access call
+
+	Bitfield bool // run the BITFIELD packed-integer workload
+
+	Duration time.Duration // run each fetch strategy for this long instead of once (0 = off)
+
+	KeySlotReport bool // report CLUSTER KEYSLOT distribution of inserted keys
+
+	FetchMissRate float64 // fraction of fetched keys that were never inserted
+
+	LCS        bool  // run the LCS string-comparison workload (Redis >= 7.0)
+	LCSLengths []int // string lengths compared by the LCS workload
+
+	PhaseTimeout time.Duration // budget for each fetch phase (0 = unbounded)
+
+	EncodingTransitions bool // run the OBJECT ENCODING growth workload
+
+	LuaHMGet  bool     // run the Lua HMGET vs pipelined HMGET workload
+	LuaFields []string // hash fields fetched by the Lua HMGET workload
+
+	AOFCompare bool // run the appendfsync everysec vs always workload
+
+	Normalize bool // show fetch times as speedups over direct in the table
+
+	ReplicaAddr string // replica that serves the fetch phases (empty = master)
+
+	Watch   bool // run the WATCH/MULTI/EXEC optimistic-locking workload
+	Writers int  // concurrent writers in the contention workloads
+
+	HSetStruct bool // run the whole-struct vs field-by-field HSET workload
+
+	ClientName      string // CLIENT SETNAME applied to every connection (empty = none)
+	ClientNameBench bool   // run the connection-naming overhead workload
+
+	Recommend     bool    // print a weighted best-strategy pick per size
+	WeightLatency float64 // weight of per-record latency in the score
+	WeightMemory  float64 // weight of client-side allocation in the score
+
+	Sort      bool  // run the SORT / SORT_RO list workload
+	SortSizes []int // list lengths sorted by the SORT workload
+
+	ExitSummary string // write a compact JSON run-health summary here ("-" = stderr)
+
+	PipelineFlush      bool  // run the pipeline flush-interval workload
+	PipelineFlushSizes []int // commands per Exec tried by -pipeline-flush
+
+	TypeMemory bool // run the per-type bytes-per-element diagnostic
+
+	Check bool // correctness-only mode: verify the strategies on a tiny dataset and exit
+
+	NoDelay        bool // set TCP_NODELAY on every connection (Go's default)
+	NoDelayCompare bool // run the TCP_NODELAY on/off direct-fetch comparison
+
+	Counters bool // run the INCR vs Lua vs WATCH counter workload
+
+	PrefixScan bool // run the SCAN MATCH selectivity benchmark
+
+	Envelope        map[string]string // metadata wrapped around every stored record; nil stores bare records
+	EnvelopeCompare bool              // run the bare vs enveloped record workload
+
+	ZAddGT bool // run the ZADD GT vs unconditional leaderboard workload
+
+	ConcurrencySweep int    // highest worker count in the concurrency sweep (0 = off)
+	Output           string // results format: table, csv or json
+
+	Rename bool // run the stage-then-RENAME workload
+
+	MGetSweep bool  // run the MGET batch-size sweep
+	MGetSizes []int // keys per MGET tried by -mget-sweep
+
+	SQLite string // append each run's results to this SQLite database file ("" = off)
+	Label  string // free-form run label stored alongside -sqlite results
+
+	Copy   bool // run the cross-DB COPY vs DUMP/RESTORE workload
+	CopyDB int  // target logical DB of the -copy workload
+
+	ContinueOnError bool // log a failing workload and carry on instead of exiting
+	QuietErrors     int  // identical errors logged individually before being summarised
+
+	SMIsMember bool // run the SMISMEMBER vs SISMEMBER workload
+
+	RunID string // embedded in every key as bench:<RunID>:, random unless set
+
+	Runs    int  // passes of each fetch phase per size, aggregated into mean ± stddev
+	ShowP99 bool // add each phase's p99 over -runs passes to the table
+
+	Workers int // goroutines used by the concurrent fetch strategy
+
+	// Final cleanup strategy: "tracked" deletes the recorded keys,
+	// "scan" sweeps the run's key prefix with SCAN instead of holding
+	// every key in memory
+	Cleanup string
+
+	// Expiry set on every JSON and hash key (0 = none), and whether to
+	// wait it out after each size and check the keys are gone
+	TTL       time.Duration
+	TTLVerify bool
+
+	// Records queued per Exec in the pipeline fetch
+	PipeBatch int
+
+	// Talk to a Redis Cluster through the -addrs seed nodes instead of
+	// the single server at -addr
+	Cluster bool
+	Addrs   []string
+
+	// Seed for every generated value, and whether -seed was given at all;
+	// without it records come from crypto/rand and differ on every run
+	Seed   int64
+	Seeded bool
+
+	Warmup int // untimed passes of every fetch strategy before the measured ones
+
+	// TLS to the server: client certificate, trusted CA and whether to skip
+	// verification, loaded into TLSConfig by parseFlags (nil without -tls)
+	TLS           bool
+	TLSCert       string
+	TLSKey        string
+	TLSCA         string
+	TLSSkipVerify bool
+	TLSConfig     *tls.Config
+
+	Encoding string // how records are serialised under the JSON key: json, gob or msgpack
+
+	Strategies []fetchStrategy // fetch phases run at every size, from -strategies
+
+	Progress bool // always report insert progress on stderr, not only on a terminal
+
+	SaveBaseline    string  // -save-baseline file, "" to skip
+	CompareBaseline string  // -compare-baseline file, "" to skip
+	FailOnRegress   float64 // exit nonzero when a strategy is this many percent slower than the baseline, 0 to never
+
+	PoolSize  int   // connections per client pool, set by the -pool-sizes sweep; 0 is the go-redis default of 10 per CPU
+	PoolSizes []int // pool sizes tried by the -pool-sizes sweep
+
+	Compress bool // gzip every value stored under a record's JSON key, and run the plain vs gzip workload
+
+	SweepOutput string // -concurrency-sweep CSV file under -output csv, "" to print the sweep table on stderr
+}
+
+// parseFlags reads the command line into a Config and validates it.
+func parseFlags() Config {
+	var cfg Config
+	flag.StringVar(&cfg.Addr, "addr", envOr("REDIS_ADDR", "localhost:6379"),
+		"host:port of the Redis server (env REDIS_ADDR)")
+	flag.IntVar(&cfg.DB, "db", envInt("REDIS_DB", 0),
+		"logical DB the benchmark writes to, and flushes unless -no-flush (env REDIS_DB)")
+	flag.StringVar(&cfg.Password, "password", os.Getenv("REDIS_PASSWORD"),
+		"password for AUTH (env REDIS_PASSWORD)")
+	flag.BoolVar(&cfg.NoFlush, "no-flush", false,
+		"never FLUSHDB; between sizes delete only the keys this run inserted, for use on shared servers")
+	flag.IntVar(&cfg.ResultsDB, "results-db", -1,
+		"store each run's results in this Redis DB (-1 disables; must not be the benchmark DB)")
+	flag.StringVar(&cfg.ResultsStream, "results-stream", "benchresults:runs",
+		"stream key that holds the run history inside -results-db")
+	flag.Float64Var(&cfg.CollisionRate, "collision-rate", 0,
+		"fraction of inserts in [0,1) that reuse an earlier record's key")
+	flag.IntVar(&cfg.WorkloadSize, "workload-size", 1000,
+		"number of records used by each optional workload")
+	flag.BoolVar(&cfg.GetEx, "getex", false,
+		"benchmark GETEX against GET followed by EXPIRE (Redis >= 6.2)")
+	flag.DurationVar(&cfg.GetExTTL, "getex-ttl", time.Minute,
+		"TTL set by the -getex workload")
+	flag.BoolVar(&cfg.CappedList, "capped-list", false,
+		"benchmark LPUSH+LTRIM keeping only the most recent records")
+	listCaps := flag.String("capped-list-caps", "10,100,1000",
+		"comma-separated list caps tried by -capped-list")
+	flag.DurationVar(&cfg.DialTimeout, "dial-timeout", 5*time.Second,
+		"timeout for establishing new connections")
+	flag.DurationVar(&cfg.ReadTimeout, "read-timeout", 3*time.Second,
+		"socket read timeout; raise it for large Lua fetches")
+	flag.DurationVar(&cfg.WriteTimeout, "write-timeout", 3*time.Second,
+		"socket write timeout")
+	flag.DurationVar(&cfg.KeepAlive, "keepalive", 5*time.Minute,
+		"TCP keepalive interval, keeps idle pooled connections from being dropped")
+	flag.BoolVar(&cfg.EvalPerKey, "eval-per-key", false,
+		"measure the fixed cost of one EVALSHA per key against plain GET")
+	flag.StringVar(&cfg.DatasetExport, "dataset-export", "",
+		"write each inserted record to this JSONL file")
+	flag.StringVar(&cfg.DatasetImport, "dataset-import", "",
+		"insert records read from this JSONL file instead of generating them")
+	flag.BoolVar(&cfg.PipelineErrors, "pipeline-errors", false,
+		"show which pipeline results survive one deliberately failing command")
+	flag.BoolVar(&cfg.ResetStats, "reset-stats", false,
+		"run CONFIG RESETSTAT before each size so server stats cover one size only")
+	flag.BoolVar(&cfg.ResetLatency, "reset-latency", false,
+		"run LATENCY RESET before each size")
+	flag.Float64Var(&cfg.MaxMemoryMB, "max-memory-mb", 0,
+		"abort the run (after cleanup) if used_memory exceeds this many MB after an insert; 0 disables")
+	flag.BoolVar(&cfg.RandomAccess, "random-access", false,
+		"benchmark HRANDFIELD (Redis >= 6.2) and SRANDMEMBER sampling")
+	flag.IntVar(&cfg.RandomCount, "random-count", 10,
+		"elements sampled per -random-access call (tried as +N and -N)")
+	flag.BoolVar(&cfg.Bitfield, "bitfield", false,
+		"compare BITFIELD-packed integers with separate keys and JSON")
+	flag.DurationVar(&cfg.Duration, "duration", 0,
+		"run each fetch strategy repeatedly for this long per size and report throughput and tail latency")
+	flag.BoolVar(&cfg.KeySlotReport, "keyslot-report", false,
+		"in cluster mode, report how inserted keys spread across slots and nodes")
+	flag.Float64Var(&cfg.FetchMissRate, "fetch-miss-rate", 0,
+		"fraction of fetches in [0,1) aimed at keys that do not exist")
+	flag.BoolVar(&cfg.LCS, "lcs", false,
+		"benchmark LCS on pairs of similar strings (Redis >= 7.0)")
+	lcsLengths := flag.String("lcs-lengths", "100,1000,5000",
+		"comma-separated string lengths compared by -lcs")
+	flag.DurationVar(&cfg.PhaseTimeout, "phase-timeout", 0,
+		"cancel any single fetch phase that runs longer than this and mark it timed out")
+	flag.BoolVar(&cfg.EncodingTransitions, "encoding-transitions", false,
+		"grow a hash, list, set and zset one element at a time and chart encoding and memory")
+	flag.BoolVar(&cfg.LuaHMGet, "lua-hmget", false,
+		"compare a Lua script doing HMGET per hash with a client-side HMGET pipeline")
+	luaFields := flag.String("lua-fields", "name,email,amount",
+		"comma-separated Record fields fetched by -lua-hmget (id, name, email, amount)")
+	flag.BoolVar(&cfg.AOFCompare, "aof-compare", false,
+		"compare insert throughput under appendfsync everysec and always (restored afterwards)")
+	flag.BoolVar(&cfg.Normalize, "normalize-by-baseline", false,
+		"show each strategy as a speedup over direct fetch (direct = 1.00x) instead of durations; stored results stay raw")
+	flag.StringVar(&cfg.ReplicaAddr, "replica-addr", "",
+		"run fetch strategies against this read replica (READONLY); writes and cleanup stay on the master")
+	flag.BoolVar(&cfg.Watch, "watch", false,
+		"benchmark WATCH/MULTI/EXEC read-modify-write under concurrent writers")
+	flag.IntVar(&cfg.Writers, "writers", 8,
+		"concurrent writers used by contention workloads such as -watch")
+	flag.BoolVar(&cfg.HSetStruct, "hset-struct", false,
+		"compare HSET of a whole Record with one HSET call per field")
+	flag.StringVar(&cfg.ClientName, "client-name", "",
+		"tag every connection with CLIENT SETNAME via OnConnect")
+	flag.BoolVar(&cfg.ClientNameBench, "client-name-bench", false,
+		"measure the per-connection cost of CLIENT SETNAME (uses -client-name, default redis-bench)")
+	flag.BoolVar(&cfg.Recommend, "recommend", false,
+		"score every strategy per size and recommend one, weighting latency and memory")
+	flag.Float64Var(&cfg.WeightLatency, "weight-latency", 1,
+		"-recommend weight for per-record latency (and so throughput)")
+	flag.Float64Var(&cfg.WeightMemory, "weight-memory", 0.5,
+		"-recommend weight for client-side bytes allocated while fetching")
+	flag.BoolVar(&cfg.Sort, "sort", false,
+		"benchmark SORT and SORT_RO (Redis >= 7.0) with LIMIT and BY/GET on growing lists")
+	sortSizes := flag.String("sort-sizes", "100,1000,10000",
+		"comma-separated list lengths sorted by -sort")
+	flag.StringVar(&cfg.ExitSummary, "emit-exit-summary-json", "",
+		"at exit, write a compact JSON run summary (status, errors, SLO violations, runtime) to this file, or - for stderr")
+	flag.BoolVar(&cfg.PipelineFlush, "pipeline-flush", false,
+		"benchmark splitting one pipelined fetch into Execs of N commands each")
+	flushSizes := flag.String("pipeline-flush-sizes", "1,10,100,1000,10000",
+		"comma-separated commands per Exec for -pipeline-flush; sizes >= -workload-size mean one Exec")
+	flag.BoolVar(&cfg.TypeMemory, "type-memory", false,
+		"measure used_memory growth per added string, hash field, set member, list element and zset member")
+	flag.BoolVar(&cfg.Check, "check", false,
+		"skip benchmarking; insert 10 records, verify every fetch strategy returns them, and exit non-zero on mismatch")
+	flag.BoolVar(&cfg.NoDelay, "tcp-nodelay", true,
+		"set TCP_NODELAY on connections; false re-enables Nagle's algorithm")
+	flag.BoolVar(&cfg.NoDelayCompare, "nodelay-compare", false,
+		"compare direct-fetch latency with TCP_NODELAY on and off")
+	flag.BoolVar(&cfg.Counters, "counters", false,
+		"compare concurrent counter increments via INCR, a Lua script and WATCH/MULTI/EXEC (uses -writers)")
+	flag.BoolVar(&cfg.PrefixScan, "prefix-scan-benchmark", false,
+		"benchmark SCAN MATCH throughput at varying pattern selectivity over a mixed keyspace")
+	envelope := flag.String("envelope", "",
+		"wrap each stored record in an envelope with these comma-separated key=value metadata fields, e.g. version=1,tenant=acme")
+	flag.BoolVar(&cfg.EnvelopeCompare, "envelope-compare", false,
+		"compare memory and latency of bare vs enveloped records (uses -envelope metadata, or a default set)")
+	flag.BoolVar(&cfg.ZAddGT, "zadd-gt", false,
+		"benchmark leaderboard score updates with ZADD GT vs unconditional ZADD (Redis >= 6.2)")
+	flag.IntVar(&cfg.ConcurrencySweep, "concurrency-sweep", 0,
+		"re-run each fetch strategy at 1, 2, 4, ... up to this many workers and report throughput and p99 (0 = off)")
+	flag.StringVar(&cfg.Output, "output", "table",
+		"format of the results: table, csv (a header row and one row per size) or json (one object with schema_version, timestamp, client_version, results and the -concurrency-sweep points under sweep)")
+	flag.BoolVar(&cfg.Rename, "rename", false,
+		"benchmark staging records under temp keys and promoting them with RENAME and RENAMENX")
+	flag.BoolVar(&cfg.MGetSweep, "mget-sweep", false,
+		"fetch the JSON keys with MGET at each -mget-sizes batch size and report the fastest")
+	mgetSizes := flag.String("mget-sizes", "10,100,1000,10000",
+		"comma-separated keys per MGET for -mget-sweep")
+	flag.StringVar(&cfg.SQLite, "sqlite", "",
+		"append this run's results to a SQLite database at this path, creating its tables if absent")
+	flag.StringVar(&cfg.Label, "label", "",
+		"label stored with the -sqlite run, e.g. a branch or experiment name")
+	flag.BoolVar(&cfg.Copy, "copy", false,
+		"benchmark moving keys to -copy-db with COPY ... DB ... REPLACE versus DUMP/RESTORE (Redis >= 6.2)")
+	flag.IntVar(&cfg.CopyDB, "copy-db", 1,
+		"target logical DB of -copy; must differ from -db and -results-db")
+	flag.BoolVar(&cfg.ContinueOnError, "continue-on-error", false,
+		"log failing workloads and keep going; the run still exits non-zero after cleanup")
+	flag.IntVar(&cfg.QuietErrors, "quiet-errors-threshold", 10,
+		"log at most this many identical errors individually, then only count them (0 = log every error)")
+	flag.BoolVar(&cfg.SMIsMember, "smismember", false,
+		"benchmark batched SMISMEMBER membership checks against repeated SISMEMBER (Redis >= 6.2)")
+	flag.StringVar(&cfg.RunID, "run-id", "",
+		"ID embedded in every key (bench:<id>:...) so concurrent runs never collide; random if empty, implies -no-flush if set")
+	flag.IntVar(&cfg.Runs, "runs", 5,
+		"repeat each fetch phase this many times per size and report mean ± stddev")
+	flag.BoolVar(&cfg.ShowP99, "show-p99", false,
+		"also show the p99 of each fetch phase's -runs passes")
+	flag.IntVar(&cfg.Workers, "workers", runtime.GOMAXPROCS(0),
+		"goroutines splitting the records in the concurrent fetch strategy")
+	flag.StringVar(&cfg.Cleanup, "cleanup", "tracked",
+		"final cleanup: tracked deletes the recorded keys, scan sweeps the run's key prefix with SCAN")
+	flag.DurationVar(&cfg.TTL, "ttl", 0,
+		"expiry set on every JSON and hash key when written (0 = none)")
+	flag.BoolVar(&cfg.TTLVerify, "ttl-verify", false,
+		"after each size, sleep past -ttl and check with EXISTS that every key expired")
+	flag.IntVar(&cfg.PipeBatch, "pipe-batch", 1000,
+		"records (GET + HGET pairs) queued per Exec in the pipeline fetch")
+	flag.BoolVar(&cfg.Cluster, "cluster", false,
+		"connect to a Redis Cluster through -addrs; records are hash-tagged per ID and the lua and batch strategies run per slot; the workloads that need one node are rejected")
+	addrs := flag.String("addrs", "",
+		"comma-separated cluster seed nodes for -cluster (default -addr)")
+	flag.Int64Var(&cfg.Seed, "seed", 0,
+		"seed the record generator (names, emails, amounts and IDs) so runs reproduce the same dataset (default crypto/rand)")
+	flag.IntVar(&cfg.Warmup, "warmup", 1,
+		"untimed passes of every fetch strategy per size before timing starts, to prime the connection pool and caches")
+	flag.BoolVar(&cfg.TLS, "tls", false,
+		"connect over TLS")
+	flag.StringVar(&cfg.TLSCert, "tls-cert", "",
+		"PEM client certificate for -tls (requires -tls-key)")
+	flag.StringVar(&cfg.TLSKey, "tls-key", "",
+		"PEM private key of -tls-cert")
+	flag.StringVar(&cfg.TLSCA, "tls-ca", "",
+		"PEM CA bundle that verifies the server under -tls (default system roots)")
+	flag.BoolVar(&cfg.TLSSkipVerify, "tls-skip-verify", false,
+		"accept any server certificate under -tls; for self-signed dev servers only")
+	flag.StringVar(&cfg.Encoding, "encoding", "json",
+		"serialisation of each record's value: json, gob or msgpack; the direct fetch decodes every value")
+	strategies := flag.String("strategies", strategyNames(),
+		"comma-separated fetch strategies to run at every size; the table shows only these columns")
+	flag.BoolVar(&cfg.Progress, "progress", false,
+		"report insert progress and rate on stderr even when it is not a terminal (default: only for -output table on a terminal)")
+	flag.StringVar(&cfg.SaveBaseline, "save-baseline", "",
+		"write this run's results as JSON to `file`")
+	flag.StringVar(&cfg.CompareBaseline, "compare-baseline", "",
+		"compare this run against the results in `file` written by -save-baseline")
+	flag.Float64Var(&cfg.FailOnRegress, "fail-on-regress", 0,
+		"exit 1 if any strategy is more than `pct` percent slower than the -compare-baseline run (0 = never)")
+	poolSizes := flag.String("pool-sizes", "",
+		"comma-separated pool sizes, e.g. 1,10,50; re-run the selected fetch strategies over -workload-size records with a fresh client per size and report records/s")
+	flag.BoolVar(&cfg.Compress, "compress", false,
+		"gzip each record's -encoding value before SET and gunzip it in the direct fetch; also reports plain vs gzip memory over -workload-size records")
+	flag.StringVar(&cfg.SweepOutput, "sweep-output", "",
+		"with -output csv, write the -concurrency-sweep points as CSV to this `file` (default: print the sweep table on stderr)")
+	flag.Parse()
+
+	if cfg.DB < 0 {
+		log.Fatalf("-db must not be negative, got %d", cfg.DB)
+	}
+	// The benchmark flushes its own DB before every size, so history kept
+	// there would be wiped by the very next run.
+	if cfg.ResultsDB == cfg.DB {
+		log.Fatalf("-results-db must differ from the benchmark DB (%d)", cfg.DB)
+	}
+	if cfg.CollisionRate < 0 || cfg.CollisionRate >= 1 {
+		log.Fatalf("-collision-rate must be in [0,1), got %v", cfg.CollisionRate)
+	}
+	if cfg.WorkloadSize <= 0 {
+		log.Fatalf("-workload-size must be positive, got %d", cfg.WorkloadSize)
+	}
+	if cfg.GetExTTL <= 0 {
+		log.Fatalf("-getex-ttl must be positive, got %v", cfg.GetExTTL)
+	}
+	caps, err := parseIntList(*listCaps)
+	if err != nil {
+		log.Fatalf("-capped-list-caps: %v", err)
+	}
+	cfg.ListCaps = caps
+	for name, d := range map[string]time.Duration{
+		"-dial-timeout":  cfg.DialTimeout,
+		"-read-timeout":  cfg.ReadTimeout,
+		"-write-timeout": cfg.WriteTimeout,
+		"-keepalive":     cfg.KeepAlive,
+	} {
+		if d < 0 {
+			log.Fatalf("%s must not be negative, got %v", name, d)
+		}
+	}
+	if cfg.DatasetExport != "" && cfg.DatasetImport != "" {
+		log.Fatalf("-dataset-export and -dataset-import are mutually exclusive")
+	}
+	if cfg.MaxMemoryMB < 0 {
+		log.Fatalf("-max-memory-mb must not be negative, got %v", cfg.MaxMemoryMB)
+	}
+	if cfg.RandomCount <= 0 {
+		log.Fatalf("-random-count must be positive, got %d", cfg.RandomCount)
+	}
+	if cfg.Duration < 0 {
+		log.Fatalf("-duration must not be negative, got %v", cfg.Duration)
+	}
+	if cfg.FetchMissRate < 0 || cfg.FetchMissRate >= 1 {
+		log.Fatalf("-fetch-miss-rate must be in [0,1), got %v", cfg.FetchMissRate)
+	}
+	if cfg.LCSLengths, err = parseIntList(*lcsLengths); err != nil {
+		log.Fatalf("-lcs-lengths: %v", err)
+	}
+	if cfg.PhaseTimeout < 0 {
+		log.Fatalf("-phase-timeout must not be negative, got %v", cfg.PhaseTimeout)
+	}
+	for _, f := range strings.Split(*luaFields, ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+		if _, ok := recordField(Record{}, f); !ok {
+			log.Fatalf("-lua-fields: unknown field %q", f)
+		}
+		cfg.LuaFields = append(cfg.LuaFields, f)
+	}
+	if len(cfg.LuaFields) == 0 {
+		log.Fatalf("-lua-fields must name at least one field")
+	}
+	if cfg.Writers <= 0 {
+		log.Fatalf("-writers must be positive, got %d", cfg.Writers)
+	}
+	if cfg.WeightLatency < 0 || cfg.WeightMemory < 0 || cfg.WeightLatency+cfg.WeightMemory == 0 {
+		log.Fatalf("-weight-latency and -weight-memory must be non-negative and not both zero")
+	}
+	if cfg.SortSizes, err = parseIntList(*sortSizes); err != nil {
+		log.Fatalf("-sort-sizes: %v", err)
+	}
+	if cfg.PipelineFlushSizes, err = parseIntList(*flushSizes); err != nil {
+		log.Fatalf("-pipeline-flush-sizes: %v", err)
+	}
+	if *envelope != "" {
+		if cfg.Envelope, err = parseMeta(*envelope); err != nil {
+			log.Fatalf("-envelope: %v", err)
+		}
+	}
+	if cfg.ConcurrencySweep < 0 {
+		log.Fatalf("-concurrency-sweep must not be negative, got %d", cfg.ConcurrencySweep)
+	}
+	switch cfg.Output {
+	case "table", "csv", "json":
+	default:
+		log.Fatalf("-output must be table, csv or json, got %q", cfg.Output)
+	}
+	if cfg.MGetSizes, err = parseIntList(*mgetSizes); err != nil {
+		log.Fatalf("-mget-sizes: %v", err)
+	}
+	if cfg.Copy && (cfg.CopyDB < 0 || cfg.CopyDB == cfg.DB || cfg.CopyDB == cfg.ResultsDB) {
+		log.Fatalf("-copy-db must be a DB other than -db and -results-db, got %d", cfg.CopyDB)
+	}
+	if cfg.QuietErrors < 0 {
+		log.Fatalf("-quiet-errors-threshold must not be negative, got %d", cfg.QuietErrors)
+	}
+	//    An explicit -run-id means sharing the server with other runs,
+	//    which a per-size FLUSHDB would wipe, so it implies -no-flush
+	if cfg.RunID == "" {
+		cfg.RunID = strings.SplitN(uuid.New().String(), "-", 2)[0]
+	} else {
+		cfg.NoFlush = true
+	}
+	if strings.ContainsAny(cfg.RunID, ":*?[]\\ ") {
+		log.Fatalf("-run-id must not contain ':', glob characters or spaces, got %q", cfg.RunID)
+	}
+	if cfg.Runs <= 0 {
+		log.Fatalf("-runs must be positive, got %d", cfg.Runs)
+	}
+	if cfg.Workers <= 0 {
+		log.Fatalf("-workers must be positive, got %d", cfg.Workers)
+	}
+	switch cfg.Cleanup {
+	case "tracked", "scan":
+	default:
+		log.Fatalf("-cleanup must be tracked or scan, got %q", cfg.Cleanup)
+	}
+	if cfg.TTL < 0 {
+		log.Fatalf("-ttl must not be negative, got %v", cfg.TTL)
+	}
+	if cfg.TTLVerify && cfg.TTL == 0 {
+		log.Fatalf("-ttl-verify requires -ttl")
+	}
+	if cfg.PipeBatch < 1 {
+		log.Fatalf("-pipe-batch must be at least 1, got %d", cfg.PipeBatch)
+	}
+	for _, a := range strings.Split(*addrs, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			cfg.Addrs = append(cfg.Addrs, a)
+		}
+	}
+	if len(cfg.Addrs) > 0 && !cfg.Cluster {
+		log.Fatalf("-addrs requires -cluster")
+	}
+	if len(cfg.Addrs) == 0 {
+		cfg.Addrs = []string{cfg.Addr}
+	}
+	//    A cluster has only DB 0 and its replicas are found through the
+	//    cluster, so the options that pick another DB or node do not apply,
+	//    and neither do the workloads that need one node
+	if cfg.Cluster {
+		switch {
+		case cfg.DB != 0:
+			log.Fatalf("-cluster supports only -db 0, got %d", cfg.DB)
+		case cfg.ResultsDB >= 0:
+			log.Fatalf("-cluster cannot be combined with -results-db")
+		case cfg.Copy:
+			log.Fatalf("-cluster cannot be combined with -copy")
+		case cfg.ReplicaAddr != "":
+			log.Fatalf("-cluster cannot be combined with -replica-addr")
+		case clusterUnsafeWorkload(cfg) != "":
+			log.Fatalf("-cluster cannot be combined with the %s workload: it needs a single node", clusterUnsafeWorkload(cfg))
+		}
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			cfg.Seeded = true
+		}
+	})
+	if cfg.Warmup < 0 {
+		log.Fatalf("-warmup must not be negative, got %d", cfg.Warmup)
+	}
+	if !cfg.TLS && (cfg.TLSCert != "" || cfg.TLSKey != "" || cfg.TLSCA != "" || cfg.TLSSkipVerify) {
+		log.Fatalf("-tls-cert, -tls-key, -tls-ca and -tls-skip-verify require -tls")
+	}
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		log.Fatalf("-tls-cert and -tls-key must be given together")
+	}
+	if cfg.TLS {
+		if cfg.TLSConfig, err = loadTLSConfig(cfg); err != nil {
+			log.Fatalf("-tls: %v", err)
+		}
+	}
+	switch cfg.Encoding {
+	case "json", "gob", "msgpack":
+	default:
+		log.Fatalf("-encoding must be json, gob or msgpack, got %q", cfg.Encoding)
+	}
+	if cfg.Strategies, err = selectStrategies(*strategies); err != nil {
+		log.Fatalf("-strategies: %v", err)
+	}
+	if cfg.Normalize && !strategySelected(cfg, "direct") {
+		log.Fatalf("-normalize-by-baseline needs direct in -strategies")
+	}
+	if cfg.Duration > 0 && !strategySelected(cfg, "direct") && !strategySelected(cfg, "pipeline") && !strategySelected(cfg, "lua") {
+		log.Fatalf("-duration times only direct, pipeline and lua; select at least one in -strategies")
+	}
+	if cfg.FailOnRegress < 0 {
+		log.Fatalf("-fail-on-regress must be >= 0 (got %g)", cfg.FailOnRegress)
+	}
+	if cfg.FailOnRegress > 0 && cfg.CompareBaseline == "" {
+		log.Fatalf("-fail-on-regress requires -compare-baseline")
+	}
+	if *poolSizes != "" {
+		if cfg.PoolSizes, err = parseIntList(*poolSizes); err != nil {
+			log.Fatalf("-pool-sizes: %v", err)
+		}
+	}
+	if cfg.SweepOutput != "" && (cfg.Output != "csv" || cfg.ConcurrencySweep == 0) {
+		log.Fatalf("-sweep-output needs -output csv and -concurrency-sweep")
+	}
+	return cfg
+}
+
+// parseIntList parses a comma-separated list of positive integers.
+func parseIntList(s string) ([]int, error) {
+	var out []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		v, err := strconv.Atoi(part)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("%q is not a positive integer", part)
+		}
+		out = append(out, v)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty list")
+	}
+	return out, nil
+}
+
+// parseMeta parses a comma-separated list of key=value pairs.
+func parseMeta(s string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(part, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("%q is not key=value", part)
+		}
+		out[k] = v
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty list")
+	}
+	return out, nil
+}
+
+// envOr returns the environment variable key, or def if it is unset or
+// empty. Flags given on the command line still override it.
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envInt is envOr for integers; a malformed value is fatal.
+func envInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("%s=%q is not an integer", key, v)
+	}
+	return n
+}
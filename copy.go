@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"  // for formatted I/O
+	"time" // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// runCopy seeds JSON keys in the benchmark DB and moves them into
+// -copy-db three ways: COPY ... DB into an empty target, DUMP on the
+// source plus RESTORE on a second client into an empty target, and COPY
+// ... DB ... REPLACE over keys already there. COPY stays server-side;
+// DUMP/RESTORE ships every serialized value through the client twice.
+// The target keys are compared with the source and deleted afterwards.
+func runCopy(rdb redis.UniversalClient, cfg Config) error {
+	ok, err := versionAtLeast(rdb, "6.2")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("⏭  COPY workload skipped: requires Redis >= 6.2")
+		return nil
+	}
+
+	n := cfg.WorkloadSize
+	keys, err := seedJSONKeys(rdb, keyPrefix+"copy:", n)
+	defer deleteInsertedKeys(rdb, keys)
+	if err != nil {
+		return err
+	}
+	target := newClient(cfg, cfg.CopyDB)
+	defer target.Close()
+	defer deleteInsertedKeys(target, keys)
+
+	methods := []struct {
+		name    string
+		replace bool // target keys exist beforehand
+		move    func(key string) error
+	}{
+		{"COPY DB", false, func(key string) error {
+			return checkCopied(rdb.Copy(ctx, key, key, cfg.CopyDB, false), key)
+		}},
+		{"DUMP/RESTORE", false, func(key string) error {
+			dump, err := rdb.Dump(ctx, key).Result()
+			if err != nil {
+				return fmt.Errorf("DUMP %s failed: %w", key, err)
+			}
+			if err := target.Restore(ctx, key, 0, dump).Err(); err != nil {
+				return fmt.Errorf("RESTORE %s failed: %w", key, err)
+			}
+			return nil
+		}},
+		{"COPY DB REPLACE", true, func(key string) error {
+			return checkCopied(rdb.Copy(ctx, key, key, cfg.CopyDB, true), key)
+		}},
+	}
+
+	fmt.Printf("Cross-DB key copy (%d keys, DB %d → %d)\n", n, cfg.DB, cfg.CopyDB)
+	for _, m := range methods {
+		if !m.replace {
+			if err := deleteInsertedKeys(target, keys); err != nil {
+				return err
+			}
+		}
+		t0 := time.Now()
+		for _, key := range keys {
+			if err := m.move(key); err != nil {
+				return err
+			}
+		}
+		dur := time.Since(t0)
+		if err := compareCopies(rdb, target, keys); err != nil {
+			return fmt.Errorf("%s: %w", m.name, err)
+		}
+		fmt.Printf("  %-15s | %12v | %.0f keys/s\n", m.name, dur, float64(n)/dur.Seconds())
+	}
+	return nil
+}
+
+// checkCopied turns a COPY reply of 0, which means the target existed and
+// REPLACE was not given, into an error.
+func checkCopied(cmd *redis.IntCmd, key string) error {
+	copied, err := cmd.Result()
+	if err != nil {
+		return fmt.Errorf("COPY %s failed: %w", key, err)
+	}
+	if copied == 0 {
+		return fmt.Errorf("COPY %s did not copy: target exists", key)
+	}
+	return nil
+}
+
+// compareCopies verifies every key holds the same value in both DBs.
+func compareCopies(src, dst redis.UniversalClient, keys []string) error {
+	for _, key := range keys {
+		want, err := src.Get(ctx, key).Result()
+		if err != nil {
+			return fmt.Errorf("GET %s failed: %w", key, err)
+		}
+		got, err := dst.Get(ctx, key).Result()
+		if err != nil {
+			return fmt.Errorf("GET %s in target DB failed: %w", key, err)
+		}
+		if got != want {
+			return fmt.Errorf("%s differs in the target DB", key)
+		}
+	}
+	return nil
+}
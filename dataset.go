@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"         // for buffered dataset files
+	"encoding/json" // for JSONL encoding
+	"errors"        // for detecting end of file
+	"fmt"           // for wrapping errors
+	"io"            // for io.EOF
+	"os"            // for dataset files
+)
+
+// recordSource yields the records to insert: freshly generated ones, or the
+// contents of a -dataset-import file. With -dataset-export, every record
+// accepted by the caller is also appended to a JSONL file.
+//
+// Records are consumed in order across all sample sizes, so a file exported
+// by one run replays exactly when imported with the same sizes.
+type recordSource struct {
+	in  *json.Decoder // set when importing
+	out *json.Encoder // set when exporting
+	buf *bufio.Writer // buffers out, flushed by Close
+	f   *os.File      // the open dataset file, if any
+}
+
+// openRecordSource prepares the record source selected by cfg.
+func openRecordSource(cfg Config) (*recordSource, error) {
+	src := &recordSource{}
+	switch {
+	case cfg.DatasetImport != "":
+		f, err := os.Open(cfg.DatasetImport)
+		if err != nil {
+			return nil, fmt.Errorf("open dataset: %w", err)
+		}
+		src.f = f
+		src.in = json.NewDecoder(bufio.NewReader(f))
+	case cfg.DatasetExport != "":
+		f, err := os.Create(cfg.DatasetExport)
+		if err != nil {
+			return nil, fmt.Errorf("create dataset: %w", err)
+		}
+		src.f = f
+		src.buf = bufio.NewWriter(f)
+		src.out = json.NewEncoder(src.buf)
+	}
+	return src, nil
+}
+
+// imported reports whether records come from a dataset file.
+func (s *recordSource) imported() bool { return s.in != nil }
+
+// next returns the next record to insert.
+func (s *recordSource) next() (Record, error) {
+	if s.in == nil {
+		return generateRecord(), nil
+	}
+	var rec Record
+	if err := s.in.Decode(&rec); err != nil {
+		if errors.Is(err, io.EOF) {
+			return rec, fmt.Errorf("dataset exhausted; it was exported with fewer or smaller sizes")
+		}
+		return rec, fmt.Errorf("decode dataset record: %w", err)
+	}
+	return rec, nil
+}
+
+// export records rec as stored, if exporting.
+func (s *recordSource) export(rec Record) error {
+	if s.out == nil {
+		return nil
+	}
+	if err := s.out.Encode(rec); err != nil {
+		return fmt.Errorf("export record: %w", err)
+	}
+	return nil
+}
+
+// Close flushes any exported records and closes the dataset file. It is
+// safe to call more than once.
+func (s *recordSource) Close() error {
+	if s.f == nil {
+		return nil
+	}
+	f := s.f
+	s.f = nil
+	if s.buf != nil {
+		if err := s.buf.Flush(); err != nil {
+			f.Close()
+			return fmt.Errorf("flush dataset: %w", err)
+		}
+	}
+	return f.Close()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"     // for formatted I/O
+	"strconv" // for element names
+	"strings" // for chart bars
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// encodingSample is one point on a structure's growth curve.
+type encodingSample struct {
+	size     int
+	encoding string
+	bytes    int64
+}
+
+// runEncodingTransitions grows one structure of each aggregate type an
+// element at a time, recording OBJECT ENCODING and MEMORY USAGE after every
+// add. Small aggregates use a compact encoding (listpack/ziplist/intset)
+// until a *-max-*-entries threshold, then convert to a hashtable or
+// skiplist and memory jumps; the chart makes that cliff visible.
+func runEncodingTransitions(rdb redis.UniversalClient, cfg Config) error {
+	n := cfg.WorkloadSize
+	structures := []struct {
+		name string
+		key  string
+		add  func(key string, i int) error
+	}{
+		{"hash", keyPrefix + "enc:hash", func(key string, i int) error {
+			return rdb.HSet(ctx, key, "f"+strconv.Itoa(i), randStr(16)).Err()
+		}},
+		{"list", keyPrefix + "enc:list", func(key string, i int) error {
+			return rdb.RPush(ctx, key, randStr(16)).Err()
+		}},
+		{"set", keyPrefix + "enc:set", func(key string, i int) error {
+			return rdb.SAdd(ctx, key, "m"+strconv.Itoa(i)).Err()
+		}},
+		{"zset", keyPrefix + "enc:zset", func(key string, i int) error {
+			return rdb.ZAdd(ctx, key, &redis.Z{Score: float64(i), Member: "m" + strconv.Itoa(i)}).Err()
+		}},
+	}
+
+	for _, st := range structures {
+		samples := make([]encodingSample, 0, n)
+		for i := 1; i <= n; i++ {
+			if err := st.add(st.key, i); err != nil {
+				deleteInsertedKeys(rdb, []string{st.key})
+				return fmt.Errorf("growing %s failed: %w", st.key, err)
+			}
+			enc, err := rdb.ObjectEncoding(ctx, st.key).Result()
+			if err != nil {
+				deleteInsertedKeys(rdb, []string{st.key})
+				return fmt.Errorf("OBJECT ENCODING %s failed: %w", st.key, err)
+			}
+			mem, err := rdb.MemoryUsage(ctx, st.key).Result()
+			if err != nil {
+				deleteInsertedKeys(rdb, []string{st.key})
+				return fmt.Errorf("MEMORY USAGE %s failed: %w", st.key, err)
+			}
+			samples = append(samples, encodingSample{i, enc, mem})
+		}
+		if err := deleteInsertedKeys(rdb, []string{st.key}); err != nil {
+			return err
+		}
+		printEncodingChart(st.name, samples)
+	}
+	return nil
+}
+
+// printEncodingChart prints about 32 evenly spaced samples plus every
+// sample where the encoding changed, with a bar proportional to memory.
+func printEncodingChart(name string, samples []encodingSample) {
+	if len(samples) == 0 {
+		return
+	}
+	peak := samples[len(samples)-1].bytes
+	for _, s := range samples {
+		if s.bytes > peak {
+			peak = s.bytes
+		}
+	}
+	step := len(samples) / 32
+	if step == 0 {
+		step = 1
+	}
+
+	fmt.Printf("Encoding growth: %s\n", name)
+	var transitions []string
+	for i, s := range samples {
+		changed := i > 0 && s.encoding != samples[i-1].encoding
+		if changed {
+			transitions = append(transitions, fmt.Sprintf("%s→%s at %d elements",
+				samples[i-1].encoding, s.encoding, s.size))
+		}
+		if !changed && i%step != 0 && i != len(samples)-1 {
+			continue
+		}
+		mark := ""
+		if changed {
+			mark = " ◀ transition"
+		}
+		fmt.Printf("  %6d | %-10s | %8d B | %s%s\n",
+			s.size, s.encoding, s.bytes, strings.Repeat("█", int(40*s.bytes/peak)), mark)
+	}
+	if len(transitions) == 0 {
+		fmt.Printf("  no transition within %d elements (stayed %s)\n",
+			len(samples), samples[0].encoding)
+		return
+	}
+	for _, t := range transitions {
+		fmt.Printf("  %s: %s\n", name, t)
+	}
+}
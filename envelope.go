@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"     // for formatted I/O
+	"reflect" // for comparing metadata maps
+	"time"    // for envelope timestamps
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// Envelope wraps a stored Record with metadata, the way many applications
+// version and tag their payloads.
+type Envelope struct {
+	Meta     map[string]string `json:"meta"`      // -envelope key=value fields
+	StoredAt int64             `json:"stored_at"` // Unix milliseconds at marshal time
+	Record   Record            `json:"record"`    // the payload
+}
+
+// encodeRecord returns the value stored for rec in -encoding: the bare
+// record, or rec wrapped in an Envelope when meta is non-nil.
+func encodeRecord(encoding string, meta map[string]string, rec Record) ([]byte, error) {
+	if meta == nil {
+		return marshalValue(encoding, rec)
+	}
+	return marshalValue(encoding, Envelope{Meta: meta, StoredAt: time.Now().UnixMilli(), Record: rec})
+}
+
+// decodeRecord is the inverse of encodeRecord. With meta non-nil it also
+// checks that the envelope carries exactly that metadata.
+func decodeRecord(encoding string, meta map[string]string, data []byte) (Record, error) {
+	if meta == nil {
+		var rec Record
+		err := unmarshalValue(encoding, data, &rec)
+		return rec, err
+	}
+	var env Envelope
+	if err := unmarshalValue(encoding, data, &env); err != nil {
+		return env.Record, err
+	}
+	if !reflect.DeepEqual(env.Meta, meta) {
+		return env.Record, fmt.Errorf("envelope metadata %v, want %v", env.Meta, meta)
+	}
+	return env.Record, nil
+}
+
+// defaultEnvelope is the metadata -envelope-compare uses without -envelope.
+var defaultEnvelope = map[string]string{"version": "1", "tenant": "acme"}
+
+// runEnvelopeCompare stores -workload-size records bare and then wrapped
+// in an Envelope, reporting the memory, insert and fetch+decode cost of
+// the envelope, and verifies every record round-trips through it.
+func runEnvelopeCompare(rdb redis.UniversalClient, cfg Config) error {
+	meta := cfg.Envelope
+	if meta == nil {
+		meta = defaultEnvelope
+	}
+	n := cfg.WorkloadSize
+	recs := make([]Record, n)
+	for i := range recs {
+		recs[i] = generateRecord()
+	}
+
+	fmt.Printf("Record envelope overhead (%d records, meta %v)\n", n, meta)
+	fmt.Println("Shape     | ΔMem (MB) | Insert       | Fetch+decode")
+	fmt.Println("----------+-----------+--------------+-------------")
+	for _, shape := range []struct {
+		name   string
+		prefix string
+		meta   map[string]string
+	}{
+		{"bare", keyPrefix + "env:bare:", nil},
+		{"envelope", keyPrefix + "env:wrap:", meta},
+	} {
+		row, err := measureEnvelopeShape(rdb, cfg.Encoding, shape.prefix, shape.meta, recs)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%-9s | %+9.2f | %12v | %v\n", shape.name,
+			float64(row.memDelta)/1024.0/1024.0, row.insert, row.fetch)
+	}
+	fmt.Println("  every enveloped record round-tripped intact")
+	return nil
+}
+
+// shapeRow is one line of the envelope-compare and compress-compare tables.
+type shapeRow struct {
+	memDelta   int64         // used_memory growth from the inserts
+	valueBytes int           // total stored value size
+	insert     time.Duration // time to SET every record
+	fetch      time.Duration // time to GET and decode every record
+}
+
+// measureEnvelopeShape stores recs under prefix wrapped with meta (bare
+// when nil), reads each back and checks it round-trips, and deletes the
+// keys before returning.
+func measureEnvelopeShape(rdb redis.UniversalClient, encoding, prefix string, meta map[string]string, recs []Record) (shapeRow, error) {
+	var row shapeRow
+	keys := make([]string, len(recs))
+	for i, rec := range recs {
+		keys[i] = prefix + rec.ID
+	}
+	defer deleteInsertedKeys(rdb, keys)
+
+	before, err := getMemory(rdb)
+	if err != nil {
+		return row, err
+	}
+	t0 := time.Now()
+	for i, rec := range recs {
+		data, err := encodeRecord(encoding, meta, rec)
+		if err != nil {
+			return row, err
+		}
+		row.valueBytes += len(data)
+		if err := rdb.Set(ctx, keys[i], data, 0).Err(); err != nil {
+			return row, &InsertError{Key: keys[i], Phase: "SET", Err: err}
+		}
+	}
+	row.insert = time.Since(t0)
+	after, err := getMemory(rdb)
+	if err != nil {
+		return row, err
+	}
+	row.memDelta = after - before
+
+	t1 := time.Now()
+	for i, key := range keys {
+		data, err := rdb.Get(ctx, key).Bytes()
+		if err != nil {
+			return row, &FetchError{Strategy: "direct", Key: key, Err: err}
+		}
+		got, err := decodeRecord(encoding, meta, data)
+		if err == nil && got != recs[i] {
+			err = fmt.Errorf("decoded %+v, want %+v", got, recs[i])
+		}
+		if err != nil {
+			return row, fmt.Errorf("%s does not round-trip: %w", key, err)
+		}
+	}
+	row.fetch = time.Since(t1)
+	return row, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors" // for finding an error's root cause
+	"fmt"    // for formatted I/O
+)
+
+// errorLog collects the errors a -continue-on-error run survives. Errors
+// are grouped by where they happened and their root cause, so the same
+// failure hitting many keys lands in one group. Each group is logged in
+// full up to the -quiet-errors-threshold, and only counted after that.
+type errorLog struct {
+	threshold int            // 0 logs every error
+	counts    map[string]int // errors seen per group
+	order     []string       // groups in first-seen order
+}
+
+// newErrorLog returns an empty errorLog with the given threshold.
+func newErrorLog(threshold int) *errorLog {
+	return &errorLog{threshold: threshold, counts: make(map[string]int)}
+}
+
+// add records err, which happened in scope (a workload or phase name).
+func (l *errorLog) add(scope string, err error) {
+	root := err
+	for u := errors.Unwrap(root); u != nil; u = errors.Unwrap(root) {
+		root = u
+	}
+	group := fmt.Sprintf("%s: %v", scope, root)
+	if l.counts[group] == 0 {
+		l.order = append(l.order, group)
+	}
+	l.counts[group]++
+	switch c := l.counts[group]; {
+	case l.threshold == 0 || c <= l.threshold:
+		fmt.Printf("⚠️  %s: %v\n", scope, err)
+	case c == l.threshold+1:
+		fmt.Printf("⚠️  %s: further errors like this are counted, not logged\n", group)
+	}
+}
+
+// total returns how many errors were added.
+func (l *errorLog) total() int {
+	n := 0
+	for _, c := range l.counts {
+		n += c
+	}
+	return n
+}
+
+// printSummary prints the full count of every group, noting how many of
+// each were suppressed.
+func (l *errorLog) printSummary() {
+	if len(l.order) == 0 {
+		return
+	}
+	fmt.Printf("⚠️  %d errors in %d groups:\n", l.total(), len(l.order))
+	for _, group := range l.order {
+		c := l.counts[group]
+		if l.threshold > 0 && c > l.threshold {
+			fmt.Printf("  %6d × %s (... and %d more like this)\n", c, group, c-l.threshold)
+		} else {
+			fmt.Printf("  %6d × %s\n", c, group)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import "fmt" // for formatting error messages
+
+// InsertError reports a failed write while populating the dataset.
+type InsertError struct {
+	Key   string // key being written; empty for batched writes
+	Phase string // command or write path that failed, e.g. "SET" or "lua write"
+	Err   error  // underlying Redis error
+}
+
+func (e *InsertError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s failed: %v", e.Phase, e.Err)
+	}
+	return fmt.Sprintf("%s failed for key %s: %v", e.Phase, e.Key, e.Err)
+}
+
+func (e *InsertError) Unwrap() error { return e.Err }
+
+// FetchError reports a failed read in one of the fetch strategies.
+type FetchError struct {
+	Strategy string // "direct", "pipeline" or "lua"
+	Key      string // key being read; empty for batched strategies
+	Err      error  // underlying Redis error
+}
+
+func (e *FetchError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s fetch failed: %v", e.Strategy, e.Err)
+	}
+	return fmt.Sprintf("%s fetch failed for key %s: %v", e.Strategy, e.Key, e.Err)
+}
+
+func (e *FetchError) Unwrap() error { return e.Err }
+
+// PartialFetchError reports a fetch that finished but lost some records
+// to errors on their own GET or HGET. It is transient by nature, so
+// runPhase counts it in BenchResult.Failed instead of failing the phase.
+type PartialFetchError struct {
+	Strategy      string // strategy that ran the fetch
+	Failed, Total int    // records that failed, out of all fetched
+	Err           error  // first record error
+}
+
+func (e *PartialFetchError) Error() string {
+	return fmt.Sprintf("%d/%d %s fetches failed, first: %v", e.Failed, e.Total, e.Strategy, e.Err)
+}
+
+func (e *PartialFetchError) Unwrap() error { return e.Err }
+
+// CleanupError reports a failed DEL batch while removing tracked keys.
+type CleanupError struct {
+	From, To int   // bounds of the failed batch within the key list
+	Err      error // underlying Redis error
+}
+
+func (e *CleanupError) Error() string {
+	return fmt.Sprintf("failed deleting keys %d–%d: %v", e.From, e.To, e.Err)
+}
+
+func (e *CleanupError) Unwrap() error { return e.Err }
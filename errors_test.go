@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestErrorUnwrapping(t *testing.T) {
+	cause := errors.New("connection reset")
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"InsertError", &InsertError{Key: "bench:json:1", Phase: "SET", Err: cause}},
+		{"FetchError", &FetchError{Strategy: "direct", Key: "bench:json:1", Err: cause}},
+		{"PartialFetchError", &PartialFetchError{Strategy: "direct", Failed: 3, Total: 100, Err: cause}},
+		{"CleanupError", &CleanupError{From: 0, To: 1000, Err: cause}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Wrapped again the way callers add context
+			err := fmt.Errorf("size 100: %w", tt.err)
+			if !errors.Is(err, cause) {
+				t.Errorf("errors.Is does not reach the cause through %v", err)
+			}
+			if errors.Is(err, redis.Nil) {
+				t.Errorf("errors.Is matched an unrelated error")
+			}
+			var ok bool
+			switch want := tt.err.(type) {
+			case *InsertError:
+				var got *InsertError
+				ok = errors.As(err, &got) && got == want
+			case *FetchError:
+				var got *FetchError
+				ok = errors.As(err, &got) && got == want
+			case *PartialFetchError:
+				var got *PartialFetchError
+				ok = errors.As(err, &got) && got == want
+			case *CleanupError:
+				var got *CleanupError
+				ok = errors.As(err, &got) && got == want
+			}
+			if !ok {
+				t.Errorf("errors.As did not recover the %s", tt.name)
+			}
+		})
+	}
+}
+
+func TestFetchErrorWrapsPartial(t *testing.T) {
+	// concurrentFetch wraps worker errors in a FetchError, so a partial
+	// failure must still be recognisable underneath one
+	partial := &PartialFetchError{Strategy: "direct", Failed: 1, Total: 10, Err: redis.ErrClosed}
+	err := &FetchError{Strategy: "concurrent", Err: partial}
+	var got *PartialFetchError
+	if !errors.As(err, &got) || got.Failed != 1 {
+		t.Errorf("errors.As(%v) = %v, want the partial failure", err, got)
+	}
+	if !errors.Is(err, redis.ErrClosed) {
+		t.Errorf("errors.Is does not reach redis.ErrClosed through %v", err)
+	}
+}
+
+func TestErrorMessages(t *testing.T) {
+	cause := errors.New("boom")
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&InsertError{Key: "k", Phase: "SET", Err: cause}, "SET failed for key k: boom"},
+		{&InsertError{Phase: "lua write", Err: cause}, "lua write failed: boom"},
+		{&FetchError{Strategy: "pipeline", Err: cause}, "pipeline fetch failed: boom"},
+		{&FetchError{Strategy: "direct", Key: "k", Err: cause}, "direct fetch failed for key k: boom"},
+		{&PartialFetchError{Strategy: "direct", Failed: 3, Total: 100, Err: cause}, "3/100 direct fetches failed, first: boom"},
+		{&CleanupError{From: 0, To: 1000, Err: cause}, "failed deleting keys 0–1000: boom"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
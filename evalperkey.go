@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"  // for formatted I/O
+	"time" // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// getOneScript is the smallest useful script: GET of a single key.
+var getOneScript = redis.NewScript(`return redis.call("GET", KEYS[1])`)
+
+// getAllScript GETs every key in KEYS in one invocation.
+var getAllScript = redis.NewScript(`
+            local res = {}
+            for i=1,#KEYS do
+                res[i] = redis.call("GET", KEYS[i])
+            end
+            return res
+        `)
+
+// runEvalPerKey isolates the per-invocation cost of script dispatch by
+// fetching each key with its own EVALSHA and comparing that to plain GET
+// and to one batched EVALSHA over all keys.
+func runEvalPerKey(rdb redis.UniversalClient, cfg Config) error {
+	n := cfg.WorkloadSize
+	keys, err := seedJSONKeys(rdb, keyPrefix+"evalkey:", n)
+	defer deleteInsertedKeys(rdb, keys)
+	if err != nil {
+		return err
+	}
+
+	// Load both scripts up front so no timed call ships the script body
+	for _, s := range []*redis.Script{getOneScript, getAllScript} {
+		if err := s.Load(ctx, rdb).Err(); err != nil {
+			return fmt.Errorf("SCRIPT LOAD failed: %w", err)
+		}
+	}
+
+	// a) Plain GET per key
+	t0 := time.Now()
+	for _, key := range keys {
+		if err := rdb.Get(ctx, key).Err(); err != nil {
+			return fmt.Errorf("GET %s failed: %w", key, err)
+		}
+	}
+	durGet := time.Since(t0)
+
+	// b) One EVALSHA per key
+	t1 := time.Now()
+	for _, key := range keys {
+		if err := getOneScript.EvalSha(ctx, rdb, []string{key}).Err(); err != nil {
+			return fmt.Errorf("EVALSHA GET %s failed: %w", key, err)
+		}
+	}
+	durEval := time.Since(t1)
+
+	// c) One EVALSHA for all keys
+	t2 := time.Now()
+	if err := getAllScript.EvalSha(ctx, rdb, keys).Err(); err != nil {
+		return fmt.Errorf("batched EVALSHA failed: %w", err)
+	}
+	durBatch := time.Since(t2)
+
+	perCall := (durEval - durGet) / time.Duration(n)
+	fmt.Println("Per-key EVALSHA vs GET")
+	fmt.Printf("  %d keys | GET %v | EVALSHA/key %v | EVALSHA batch %v\n",
+		n, durGet, durEval, durBatch)
+	fmt.Printf("  script dispatch overhead: %v per call\n", perCall)
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"  // for formatted I/O
+	"time" // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// runGetEx compares refreshing a key's TTL on read with a single GETEX
+// against the two-command GET + EXPIRE sequence.
+func runGetEx(rdb redis.UniversalClient, cfg Config) error {
+	ok, err := versionAtLeast(rdb, "6.2")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("⏭  GETEX workload skipped: requires Redis >= 6.2")
+		return nil
+	}
+
+	n := cfg.WorkloadSize
+	keys, err := seedJSONKeys(rdb, keyPrefix+"getex:", n)
+	defer deleteInsertedKeys(rdb, keys)
+	if err != nil {
+		return err
+	}
+
+	// a) Two round-trips per key: GET, then EXPIRE
+	t0 := time.Now()
+	for _, key := range keys {
+		if err := rdb.Get(ctx, key).Err(); err != nil {
+			return fmt.Errorf("GET %s failed: %w", key, err)
+		}
+		if err := rdb.Expire(ctx, key, cfg.GetExTTL).Err(); err != nil {
+			return fmt.Errorf("EXPIRE %s failed: %w", key, err)
+		}
+	}
+	durGetExpire := time.Since(t0)
+
+	// Drop the TTLs again so the GETEX check below proves GETEX set them
+	for _, key := range keys {
+		if err := rdb.Persist(ctx, key).Err(); err != nil {
+			return fmt.Errorf("PERSIST %s failed: %w", key, err)
+		}
+	}
+
+	// b) One atomic round-trip per key: GETEX key EX ttl
+	t1 := time.Now()
+	for _, key := range keys {
+		if err := rdb.GetEx(ctx, key, cfg.GetExTTL).Err(); err != nil {
+			return fmt.Errorf("GETEX %s failed: %w", key, err)
+		}
+	}
+	durGetEx := time.Since(t1)
+
+	// c) Verify on a sample that GETEX really applied the TTL
+	if err := verifyTTLs(rdb, keys, cfg.GetExTTL); err != nil {
+		return err
+	}
+
+	saved := durGetExpire - durGetEx
+	fmt.Println("GETEX vs GET+EXPIRE")
+	fmt.Printf("  %d keys | GET+EXPIRE %v | GETEX %v | saved %v (%v/op)\n",
+		n, durGetExpire, durGetEx, saved, saved/time.Duration(n))
+	return nil
+}
+
+// verifyTTLs checks that up to 10 evenly spaced keys carry a TTL in
+// (0, ttl].
+func verifyTTLs(rdb redis.UniversalClient, keys []string, ttl time.Duration) error {
+	step := len(keys) / 10
+	if step == 0 {
+		step = 1
+	}
+	for i := 0; i < len(keys); i += step {
+		got, err := rdb.TTL(ctx, keys[i]).Result()
+		if err != nil {
+			return fmt.Errorf("TTL %s failed: %w", keys[i], err)
+		}
+		if got <= 0 || got > ttl {
+			return fmt.Errorf("key %s has TTL %v after GETEX, want (0, %v]", keys[i], got, ttl)
+		}
+	}
+	return nil
+}
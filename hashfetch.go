@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context" // for the fetch signature
+	"fmt"     // for formatted errors
+	"strconv" // for the amount field
+	"strings" // for trimming hash keys
+	"time"    // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// hashFields are the Record fields stored in each record's hash, in the
+// order HMGET asks for them.
+var hashFields = []string{"name", "email", "amount"}
+
+// recordHash returns the HSET field/value pairs of rec's hash, with the
+// amount formatted by formatAmount.
+func recordHash(rec Record) []interface{} {
+	return []interface{}{
+		"name", rec.Name,
+		"email", rec.Email,
+		"amount", formatAmount(rec.Amount),
+	}
+}
+
+// formatAmount writes a Record amount the way go-redis writes a float64
+// argument: the shortest plain decimal that parses back to the same value,
+// so it round-trips exactly and every hash holds the same text for it.
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+// recordFromHash rebuilds the Record stored under hashKey from its fields
+// as HGETALL returns them. Every one of hashFields must be present.
+func recordFromHash(hashKey string, fields map[string]string) (Record, error) {
+	for _, f := range hashFields {
+		if _, ok := fields[f]; !ok {
+			return Record{}, fmt.Errorf("field %q missing", f)
+		}
+	}
+	amount, err := strconv.ParseFloat(fields["amount"], 64)
+	if err != nil {
+		return Record{}, fmt.Errorf("amount: %w", err)
+	}
+	return Record{
+		ID:     strings.TrimSuffix(strings.TrimPrefix(hashKey, keyPrefix+"hash:{"), "}"),
+		Name:   fields["name"],
+		Email:  fields["email"],
+		Amount: amount,
+	}, nil
+}
+
+// hashFetch returns a strategy that reads only the hashes, one round-trip
+// per record through get. A missing hash is a miss, not an error; other
+// per-record errors are collected into a *PartialFetchError, as with the
+// direct strategy.
+func hashFetch(name string, get func(ctx context.Context, rdb redis.UniversalClient, key string) error) fetchFunc {
+	return func(ctx context.Context, rdb redis.UniversalClient, _, hashKeys []string) (time.Duration, error) {
+		t0 := time.Now()
+		var partial *PartialFetchError
+		for _, key := range hashKeys {
+			if err := get(ctx, rdb, key); err != nil {
+				if ctx.Err() != nil {
+					return 0, err
+				}
+				if partial == nil {
+					partial = &PartialFetchError{Strategy: name, Total: len(hashKeys), Err: &FetchError{Strategy: name, Key: key, Err: err}}
+				}
+				partial.Failed++
+			}
+		}
+		if partial != nil {
+			return time.Since(t0), partial
+		}
+		return time.Since(t0), nil
+	}
+}
+
+// fetchHGet reads the email field alone, the single-field best case.
+var fetchHGet = hashFetch("hget", func(ctx context.Context, rdb redis.UniversalClient, key string) error {
+	if err := rdb.HGet(ctx, key, "email").Err(); err != nil && err != redis.Nil {
+		return err
+	}
+	return nil
+})
+
+// fetchHMGet reads every stored field with one HMGET and rebuilds the
+// Record. HMGET answers a missing hash with all nils.
+var fetchHMGet = hashFetch("hmget", func(ctx context.Context, rdb redis.UniversalClient, key string) error {
+	vals, err := rdb.HMGet(ctx, key, hashFields...).Result()
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]string, len(hashFields))
+	for i, v := range vals {
+		if s, ok := v.(string); ok {
+			fields[hashFields[i]] = s
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	_, err = recordFromHash(key, fields)
+	return err
+})
+
+// fetchHGetAll reads the whole hash with HGETALL and rebuilds the Record
+// from the map. HGETALL answers a missing hash with an empty map.
+var fetchHGetAll = hashFetch("hgetall", func(ctx context.Context, rdb redis.UniversalClient, key string) error {
+	fields, err := rdb.HGetAll(ctx, key).Result()
+	if err != nil || len(fields) == 0 {
+		return err
+	}
+	_, err = recordFromHash(key, fields)
+	return err
+})
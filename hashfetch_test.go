@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestRecordHashRoundTrip(t *testing.T) {
+	for _, amount := range []float64{0, 0.1, 123.456789012345678, 1e-300, math.MaxFloat64, math.SmallestNonzeroFloat64} {
+		rec := Record{ID: "0b6a2c1e-4c4e-4d8e-9a4d-2f1c2b3a4d5e", Name: "abcdef", Email: "a@b.c", Amount: amount}
+		_, hashKey := recordKeys(rec.ID)
+		pairs := recordHash(rec)
+		fields := make(map[string]string)
+		for i := 0; i < len(pairs); i += 2 {
+			fields[pairs[i].(string)] = pairs[i+1].(string)
+		}
+		got, err := recordFromHash(hashKey, fields)
+		if err != nil {
+			t.Fatalf("amount %v: %v", amount, err)
+		}
+		if got != rec {
+			t.Errorf("rebuilt %+v, want %+v", got, rec)
+		}
+		// lua-hmget checks HMGET replies against the same text
+		if want, _ := recordField(rec, "amount"); fields["amount"] != want {
+			t.Errorf("recordHash amount %q, recordField %q", fields["amount"], want)
+		}
+	}
+
+	if _, err := recordFromHash("k", map[string]string{"name": "n", "email": "e"}); err == nil {
+		t.Error("a hash without an amount rebuilt a Record")
+	}
+	if _, err := recordFromHash("k", map[string]string{"name": "n", "email": "e", "amount": "1,5"}); err == nil {
+		t.Error(`amount "1,5" parsed`)
+	}
+}
+
+func TestHashFetches(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ds := insertTestRecords(t, rdb, 20)
+	fetch, misses := ds.withMisses(0.5)
+	if misses == 0 {
+		t.Fatal("withMisses swapped in no misses")
+	}
+
+	for name, fn := range map[string]fetchFunc{"hget": fetchHGet, "hmget": fetchHMGet, "hgetall": fetchHGetAll} {
+		if _, err := fn(ctx, rdb, fetch.jsonKeys, fetch.hashKeys); err != nil {
+			t.Errorf("%s over hits and misses: %v", name, err)
+		}
+	}
+
+	// A hash that lost a field fails only its own record
+	if err := rdb.HDel(ctx, ds.hashKeys[0], "amount").Err(); err != nil {
+		t.Fatal(err)
+	}
+	for name, fn := range map[string]fetchFunc{"hmget": fetchHMGet, "hgetall": fetchHGetAll} {
+		_, err := fn(ctx, rdb, ds.jsonKeys, ds.hashKeys)
+		var partial *PartialFetchError
+		if !errors.As(err, &partial) || partial.Failed != 1 {
+			t.Errorf("%s with one broken hash: %v, want 1 failed record", name, err)
+		}
+	}
+}
+
+func TestInsertStoresHashFields(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ds := insertTestRecords(t, rdb, 5)
+	// The write phases rewrite the same fields
+	if _, err := writeLua(ctx, rdb, ds); err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range ds.hashKeys {
+		fields, err := rdb.HGetAll(ctx, key).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec, err := recordFromHash(key, fields)
+		if err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		want, err := decodeRecord("", nil, []byte(ds.values[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec != want || len(fields) != len(hashFields) {
+			t.Errorf("%s holds %v, want the fields of %+v", key, fields, want)
+		}
+	}
+}
+
+func TestCheckCoversHashes(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	out := captureStdout(t, func() {
+		if err := runCheck(rdb, Config{}); err != nil {
+			t.Errorf("runCheck: %v", err)
+		}
+	})
+	if !strings.Contains(out, "Check passed") {
+		t.Errorf("runCheck did not pass:\n%s", out)
+	}
+}
+
+func TestRecommendSkipsHashOnly(t *testing.T) {
+	r := BenchResult{Count: 10, Direct: 10e6, HGet: 1e6, HGetAll: 2e6}
+	for _, s := range scoreSize(Config{WeightLatency: 1}, r) {
+		if s.name != "direct" {
+			t.Errorf("scored %s, want only full-record strategies", s.name)
+		}
+	}
+}
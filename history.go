@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json" // for (un)marshaling run records
+	"fmt"           // for formatted I/O
+	"strings"       // for building the comparison table
+	"time"          // for run timestamps
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// resultsSchemaVersion identifies the layout of the JSON run document.
+// Bump it whenever a field is removed, renamed or changes meaning, so
+// consumers can tell which layout they are reading. Adding a field does
+// not require a bump.
+//
+//	1: timestamp, client_version and per-size results
+//	2: direct_ns, pipeline_ns and lua_ns are the mean over -runs passes,
+//	   not a single pass
+//	3: direct_ns includes decoding every value in the run's -encoding
+//	4: hashes hold name, email and amount, not just the email, so
+//	   hash_bytes and the write_*_ns times cover all three fields
+//	5: concurrent_ns includes decoding every value, like direct_ns
+const resultsSchemaVersion = 5
+
+// RunRecord is one benchmark run as stored in the results history stream.
+type RunRecord struct {
+	SchemaVersion int           `json:"schema_version"` // see resultsSchemaVersion
+	Timestamp     time.Time     `json:"timestamp"`
+	ClientVersion string        `json:"client_version"` // go-redis module version
+	Results       []BenchResult `json:"results"`
+	Sweep         []SweepPoint  `json:"sweep,omitempty"` // -concurrency-sweep, -output json only
+}
+
+// recordResults appends this run to the history stream in cfg.ResultsDB and
+// prints how it compares to the previous entry, if there is one.
+//
+// The history lives in its own logical DB under a key outside the bench:*
+// namespace, so neither FLUSHDB nor the tracked-key cleanup can reach it.
+func recordResults(cfg Config, results []BenchResult) error {
+	hdb := newClient(cfg, cfg.ResultsDB)
+	defer hdb.Close()
+
+	// Fetch the previous run before adding ours, so we compare against it
+	prev, err := lastRun(hdb, cfg.ResultsStream)
+	if err != nil {
+		return err
+	}
+
+	run := RunRecord{
+		SchemaVersion: resultsSchemaVersion,
+		Timestamp:     time.Now().UTC(),
+		ClientVersion: clientVersion(),
+		Results:       results,
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("marshal run record: %w", err)
+	}
+	id, err := hdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: cfg.ResultsStream,
+		Values: map[string]interface{}{
+			"timestamp": run.Timestamp.Format(time.RFC3339),
+			"run":       data,
+		},
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("XADD %s failed: %w", cfg.ResultsStream, err)
+	}
+	fmt.Printf("📝 Stored run %s in DB %d stream %s\n", id, cfg.ResultsDB, cfg.ResultsStream)
+
+	if prev != nil && prev.SchemaVersion != resultsSchemaVersion {
+		fmt.Printf("⚠️  previous run uses results schema %d, this build writes %d; skipping comparison\n",
+			prev.SchemaVersion, resultsSchemaVersion)
+		prev = nil
+	}
+	if prev != nil {
+		fmt.Printf("Compared with previous run (%s, go-redis %s):\n",
+			prev.Timestamp.Format(time.RFC3339), prev.ClientVersion)
+		compareResults(prev.Results, results)
+	}
+	return nil
+}
+
+// lastRun returns the most recent run in stream, or nil if it is empty.
+func lastRun(hdb redis.UniversalClient, stream string) (*RunRecord, error) {
+	msgs, err := hdb.XRevRangeN(ctx, stream, "+", "-", 1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("XREVRANGE %s failed: %w", stream, err)
+	}
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+	raw, _ := msgs[0].Values["run"].(string)
+	var run RunRecord
+	if err := json.Unmarshal([]byte(raw), &run); err != nil {
+		return nil, fmt.Errorf("decode run %s: %w", msgs[0].ID, err)
+	}
+	return &run, nil
+}
+
+// compareResults prints, for every size both prev and cur have, each fetch
+// strategy's current time and its change from prev, and returns the
+// largest slowdown in percent (0 if nothing got slower). A strategy either
+// side did not measure shows "-"; sizes only one side has are named in a
+// warning instead.
+func compareResults(prev, cur []BenchResult) (worst float64) {
+	byCount := make(map[int]BenchResult, len(prev))
+	for _, r := range prev {
+		byCount[r.Count] = r
+	}
+	var titles, bars strings.Builder
+	for _, s := range fetchStrategies {
+		fmt.Fprintf(&titles, " %-24s |", s.name)
+		bars.WriteString(strings.Repeat("-", 26) + "+")
+	}
+	fmt.Printf("Count   |%s\n", strings.TrimSuffix(titles.String(), " |"))
+	fmt.Printf("--------+%s\n", strings.TrimSuffix(bars.String(), "+"))
+
+	var onlyCur []int
+	for _, c := range cur {
+		p, ok := byCount[c.Count]
+		if !ok {
+			onlyCur = append(onlyCur, c.Count)
+			continue
+		}
+		delete(byCount, c.Count)
+		var cells strings.Builder
+		for _, s := range fetchStrategies {
+			was, now := *s.result(&p), *s.result(&c)
+			if was == 0 || now == 0 {
+				fmt.Fprintf(&cells, " %24s |", "-")
+				continue
+			}
+			pct := pctChange(was, now)
+			verdict := " slower"
+			switch {
+			case pct < 0:
+				verdict = " faster"
+			case pct == 0:
+				verdict = ""
+			}
+			fmt.Fprintf(&cells, " %24s |", fmt.Sprintf("%v %+.1f%%%s", now.Round(time.Microsecond), pct, verdict))
+			if pct > worst {
+				worst = pct
+			}
+		}
+		fmt.Printf("%6d |%s\n", c.Count, strings.TrimSuffix(cells.String(), " |"))
+	}
+
+	var onlyPrev []int
+	for _, p := range prev {
+		if _, ok := byCount[p.Count]; ok {
+			onlyPrev = append(onlyPrev, p.Count)
+		}
+	}
+	if len(onlyCur) > 0 || len(onlyPrev) > 0 {
+		fmt.Printf("⚠️  not compared: sizes %v only in this run, %v only in the earlier one\n", onlyCur, onlyPrev)
+	}
+	return worst
+}
+
+// pctChange returns how much cur differs from prev, in percent of prev.
+func pctChange(prev, cur time.Duration) float64 {
+	if prev == 0 {
+		return 0
+	}
+	return float64(cur-prev) / float64(prev) * 100
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"     // for formatted I/O
+	"reflect" // for walking struct fields
+	"strings" // for parsing struct tags
+	"time"    // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// structToHash flattens a struct into HSET field/value pairs named by its
+// json tags. go-redis v8's HSet has no struct support of its own (v9 added
+// it), so this is the reflection a caller would otherwise write by hand.
+func structToHash(v interface{}) map[string]interface{} {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	rt := rv.Type()
+	out := make(map[string]interface{}, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		name := strings.Split(rt.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		out[name] = rv.Field(i).Interface()
+	}
+	return out
+}
+
+// runHSetStruct inserts the same records as hashes twice: once with a
+// single HSET carrying the whole Record, and once with one HSET round-trip
+// per field. It then checks both layouts hold identical hashes.
+func runHSetStruct(rdb redis.UniversalClient, cfg Config) error {
+	n := cfg.WorkloadSize
+	recs := make([]Record, n)
+	var keys []string
+	for i := range recs {
+		recs[i] = generateRecord()
+		keys = append(keys, keyPrefix+"hstruct:"+recs[i].ID, keyPrefix+"hfield:"+recs[i].ID)
+	}
+	defer deleteInsertedKeys(rdb, keys)
+
+	// a) One HSET per record with every field
+	t0 := time.Now()
+	for _, rec := range recs {
+		key := keyPrefix + "hstruct:" + rec.ID
+		if err := rdb.HSet(ctx, key, structToHash(rec)).Err(); err != nil {
+			return fmt.Errorf("HSET %s failed: %w", key, err)
+		}
+	}
+	durStruct := time.Since(t0)
+
+	// b) One HSET per field
+	t1 := time.Now()
+	for _, rec := range recs {
+		key := keyPrefix + "hfield:" + rec.ID
+		for _, kv := range [][2]interface{}{
+			{"id", rec.ID}, {"name", rec.Name}, {"email", rec.Email}, {"amount", rec.Amount},
+		} {
+			if err := rdb.HSet(ctx, key, kv[0], kv[1]).Err(); err != nil {
+				return fmt.Errorf("HSET %s %v failed: %w", key, kv[0], err)
+			}
+		}
+	}
+	durFields := time.Since(t1)
+
+	// c) Both must have produced the same hash for every record
+	for _, rec := range recs {
+		a, err := rdb.HGetAll(ctx, keyPrefix+"hstruct:"+rec.ID).Result()
+		if err != nil {
+			return fmt.Errorf("HGETALL failed: %w", err)
+		}
+		b, err := rdb.HGetAll(ctx, keyPrefix+"hfield:"+rec.ID).Result()
+		if err != nil {
+			return fmt.Errorf("HGETALL failed: %w", err)
+		}
+		if !reflect.DeepEqual(a, b) {
+			return fmt.Errorf("record %s: struct hash %v differs from field hash %v", rec.ID, a, b)
+		}
+	}
+
+	fmt.Println("HSET whole struct vs field by field")
+	fmt.Printf("  %d records | struct %v | per field %v | %.2fx faster as one call | hashes identical\n",
+		n, durStruct, durFields, float64(durFields)/float64(durStruct))
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"     // for formatted I/O
+	"sort"    // for stable node ordering
+	"strings" // for histogram bars
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// clusterSlots is the number of hash slots in a Redis Cluster.
+const clusterSlots = 16384
+
+// reportKeySlots asks the cluster which slot each key hashes to and prints
+// how the keys spread across slots and across the nodes owning them, so
+// hot-spotting from the key scheme is visible. It is a no-op outside
+// cluster mode.
+func reportKeySlots(rdb redis.UniversalClient, keys []string) error {
+	enabled, err := clusterEnabled(rdb)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		fmt.Println("⏭  Keyslot report skipped: server is not in cluster mode")
+		return nil
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// a) Slot of every key, asked in pipelined batches
+	const batchSize = 1000
+	perSlot := make([]int, clusterSlots)
+	for i := 0; i < len(keys); i += batchSize {
+		end := i + batchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		pipe := rdb.Pipeline()
+		cmds := make([]*redis.IntCmd, 0, end-i)
+		for _, key := range keys[i:end] {
+			cmds = append(cmds, pipe.ClusterKeySlot(ctx, key))
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("CLUSTER KEYSLOT failed: %w", err)
+		}
+		for _, cmd := range cmds {
+			perSlot[cmd.Val()]++
+		}
+	}
+
+	// b) Map slot ranges to the master serving them
+	ranges, err := rdb.ClusterSlots(ctx).Result()
+	if err != nil {
+		return fmt.Errorf("CLUSTER SLOTS failed: %w", err)
+	}
+	perNode := make(map[string]int)
+	for _, r := range ranges {
+		if len(r.Nodes) == 0 {
+			continue
+		}
+		for s := r.Start; s <= r.End; s++ {
+			perNode[r.Nodes[0].Addr] += perSlot[s]
+		}
+	}
+
+	used, busiest := 0, 0
+	for _, c := range perSlot {
+		if c > 0 {
+			used++
+		}
+		if c > busiest {
+			busiest = c
+		}
+	}
+	fmt.Printf("Keyslot distribution: %d keys over %d/%d slots (max %d keys in one slot)\n",
+		len(keys), used, clusterSlots, busiest)
+
+	// c) Histogram of keys per node, flagging nodes >20% off the mean
+	nodes := make([]string, 0, len(perNode))
+	for addr := range perNode {
+		nodes = append(nodes, addr)
+	}
+	sort.Strings(nodes)
+	mean := float64(len(keys)) / float64(len(nodes))
+	for _, addr := range nodes {
+		c := perNode[addr]
+		dev := (float64(c) - mean) / mean * 100
+		flag := ""
+		if dev > 20 || dev < -20 {
+			flag = "  ⚠ imbalanced"
+		}
+		fmt.Printf("  %-21s %8d %+6.1f%% %s%s\n",
+			addr, c, dev, strings.Repeat("█", int(40*float64(c)/float64(len(keys)))), flag)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"     // for formatted I/O
+	"strconv" // for naming keys
+	"time"    // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// lcsCalls is how many times each LCS variant runs per string length; LCS
+// is O(len²) server-side, so a handful of calls is already meaningful.
+const lcsCalls = 10
+
+// lcsVariants are the forms of LCS timed on each pair: the bare command
+// returns the common string, LEN only its length, and IDX the match ranges.
+var lcsVariants = []struct {
+	name string
+	args []interface{}
+}{
+	{"LCS", nil},
+	{"LCS LEN", []interface{}{"LEN"}},
+	{"LCS IDX MINMATCHLEN 4 WITHMATCHLEN", []interface{}{"IDX", "MINMATCHLEN", 4, "WITHMATCHLEN"}},
+}
+
+// runLCS stores pairs of similar strings (the second is the first with
+// roughly 10% of its characters changed) and times the three forms of
+// LCS on each. go-redis v8 has no LCS helper, so the command is sent raw.
+func runLCS(rdb redis.UniversalClient, cfg Config) error {
+	ok, err := versionAtLeast(rdb, "7.0")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("⏭  LCS workload skipped: requires Redis >= 7.0")
+		return nil
+	}
+
+	fmt.Println("LCS on similar strings")
+	fmt.Println("Length  | LCS len | Variant                            | Per call")
+	fmt.Println("--------+---------+------------------------------------+-----------")
+	for _, length := range cfg.LCSLengths {
+		if err := runLCSLength(rdb, length); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runLCSLength stores one pair of strings of the given length, prints a
+// row per variant, and deletes the pair before returning.
+func runLCSLength(rdb redis.UniversalClient, length int) error {
+	a := randStr(length)
+	b := []byte(a)
+	for i := range b {
+		if chance(0.1) {
+			b[i] = randStr(1)[0]
+		}
+	}
+	ka := keyPrefix + "lcs:" + strconv.Itoa(length) + ":a"
+	kb := keyPrefix + "lcs:" + strconv.Itoa(length) + ":b"
+	defer deleteInsertedKeys(rdb, []string{ka, kb})
+	if err := rdb.MSet(ctx, ka, a, kb, string(b)).Err(); err != nil {
+		return fmt.Errorf("MSET %s %s failed: %w", ka, kb, err)
+	}
+
+	common, err := rdb.Do(ctx, "LCS", ka, kb, "LEN").Int()
+	if err == nil && (common <= 0 || common > length) {
+		err = fmt.Errorf("LCS LEN returned %d for length %d", common, length)
+	}
+	if err != nil {
+		return fmt.Errorf("LCS %s %s failed: %w", ka, kb, err)
+	}
+
+	for _, v := range lcsVariants {
+		args := append([]interface{}{"LCS", ka, kb}, v.args...)
+		t0 := time.Now()
+		for i := 0; i < lcsCalls; i++ {
+			if err := rdb.Do(ctx, args...).Err(); err != nil {
+				return fmt.Errorf("%s failed: %w", v.name, err)
+			}
+		}
+		fmt.Printf("%7d | %7d | %-34s | %v\n",
+			length, common, v.name, time.Since(t0)/lcsCalls)
+	}
+	return nil
+}
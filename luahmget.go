@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"  // for formatted I/O
+	"time" // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// hmgetScript runs HMGET KEYS[i] ARGV... for every key, returning one
+// array of field values per hash.
+var hmgetScript = redis.NewScript(`
+            local res = {}
+            for i=1,#KEYS do
+                res[i] = redis.call("HMGET", KEYS[i], unpack(ARGV))
+            end
+            return res
+        `)
+
+// recordField returns the named field of rec formatted as go-redis writes
+// it into a hash, and whether name is a Record field at all.
+func recordField(rec Record, name string) (string, bool) {
+	switch name {
+	case "id":
+		return rec.ID, true
+	case "name":
+		return rec.Name, true
+	case "email":
+		return rec.Email, true
+	case "amount":
+		return formatAmount(rec.Amount), true
+	}
+	return "", false
+}
+
+// runLuaHMGet stores whole records as hashes and fetches cfg.LuaFields from
+// every one of them, once server-side with hmgetScript and once with a
+// client-side pipeline of HMGETs, checking every returned field against
+// the record that was written.
+func runLuaHMGet(rdb redis.UniversalClient, cfg Config) error {
+	n := cfg.WorkloadSize
+	keys := make([]string, n)
+	recs := make([]Record, n)
+	pipe := rdb.Pipeline()
+	for i := range keys {
+		recs[i] = generateRecord()
+		keys[i] = keyPrefix + "hmget:" + recs[i].ID
+		pipe.HSet(ctx, keys[i], "id", recs[i].ID, "name", recs[i].Name,
+			"email", recs[i].Email, "amount", recs[i].Amount)
+	}
+	defer deleteInsertedKeys(rdb, keys)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("populating hashes failed: %w", err)
+	}
+
+	args := make([]interface{}, len(cfg.LuaFields))
+	for i, f := range cfg.LuaFields {
+		args[i] = f
+	}
+
+	// a) Server-side: one script call for every hash
+	t0 := time.Now()
+	reply, err := hmgetScript.Run(ctx, rdb, keys, args...).Slice()
+	if err != nil {
+		return fmt.Errorf("HMGET script failed: %w", err)
+	}
+	durLua := time.Since(t0)
+	for i, row := range reply {
+		vals, _ := row.([]interface{})
+		if err := checkFields(recs[i], cfg.LuaFields, vals); err != nil {
+			return fmt.Errorf("lua: %w", err)
+		}
+	}
+
+	// b) Client-side: one pipelined HMGET per hash
+	t1 := time.Now()
+	pipe = rdb.Pipeline()
+	cmds := make([]*redis.SliceCmd, n)
+	for i, key := range keys {
+		cmds[i] = pipe.HMGet(ctx, key, cfg.LuaFields...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("HMGET pipeline failed: %w", err)
+	}
+	durPipe := time.Since(t1)
+	for i, cmd := range cmds {
+		if err := checkFields(recs[i], cfg.LuaFields, cmd.Val()); err != nil {
+			return fmt.Errorf("pipeline: %w", err)
+		}
+	}
+
+	fmt.Printf("HMGET of %v\n", cfg.LuaFields)
+	fmt.Printf("  %d hashes | Lua %v | pipeline %v | all %d fields verified\n",
+		n, durLua, durPipe, n*len(cfg.LuaFields))
+	return nil
+}
+
+// checkFields verifies that vals holds rec's fields in the given order.
+func checkFields(rec Record, fields []string, vals []interface{}) error {
+	if len(vals) != len(fields) {
+		return fmt.Errorf("record %s: got %d fields, want %d", rec.ID, len(vals), len(fields))
+	}
+	for j, f := range fields {
+		want, _ := recordField(rec, f)
+		if got, _ := vals[j].(string); got != want {
+			return fmt.Errorf("record %s field %s: got %q, want %q", rec.ID, f, got, want)
+		}
+	}
+	return nil
+}
@@ -1,14 +1,16 @@
 package main
 
 import (
-	"context"       // for passing context to Redis
-	"crypto/rand"   // for secure random numbers
-	"encoding/json" // for marshaling Record structs
-	"fmt"           // for formatted I/O
-	"log"           // for logging fatal errors
-	"math/big"      // for large random-int ranges
-	"strings"       // for parsing INFO output
-	"time"          // for measuring durations
+	"context"         // for passing context to Redis
+	"crypto/rand"     // for secure random numbers
+	"fmt"             // for formatted I/O
+	"log"             // for logging fatal errors
+	"math/big"        // for large random-int ranges
+	mrand "math/rand" // for the -seed source
+	"os"              // for the exit status
+	"strings"         // for parsing INFO output
+	"sync"            // for the seeded source lock
+	"time"            // for measuring durations
 
 	"github.com/go-redis/redis/v8" // Redis client
 	"github.com/google/uuid"       // for generating UUIDs
@@ -16,6 +18,11 @@ import (
 
 var ctx = context.Background()
 
+// keyPrefix starts every key the benchmark creates. main sets it to
+// bench:<run ID>: so cleanup only ever touches this run's keys and
+// concurrent runs against one server never collide.
+var keyPrefix = "bench:"
+
 // sampleCounts defines the sizes of data sets to benchmark.
 var sampleCounts = []int{10, 100, 1_000, 10_000, 100_000}
 
@@ -27,147 +34,241 @@ type Record struct {
 	Amount float64 `json:"amount"` // random float amount
 }
 
+// BenchResult holds the measurements for a single sample size.
+type BenchResult struct {
+	Count         int           `json:"count"`             // number of records inserted
+	Distinct      int           `json:"distinct"`          // records left after overwrites
+	DeltaMB       float64       `json:"delta_mb"`          // used_memory growth after insertion
+	Encoding      string        `json:"encoding"`          // -encoding of the stored records, +gzip with -compress
+	JSONBytes     float64       `json:"json_bytes"`        // MEMORY USAGE per JSON key, sampled mean
+	HashBytes     float64       `json:"hash_bytes"`        // MEMORY USAGE per hash key, sampled mean
+	Insert        time.Duration `json:"insert_ns"`         // generate + n × (SET + HSET), serial
+	WriteDirect   time.Duration `json:"write_direct_ns"`   // n × (SET + HSET) of the same records
+	WritePipeline time.Duration `json:"write_pipeline_ns"` // same writes, one pipelined round-trip
+	WriteLua      time.Duration `json:"write_lua_ns"`      // same writes, one server-side script
+	Direct        time.Duration `json:"direct_ns"`         // n × (GET + HGET + decode), mean over -runs
+	Pipeline      time.Duration `json:"pipeline_ns"`       // single pipelined round-trip, mean
+	Lua           time.Duration `json:"lua_ns"`            // server-side script, mean
+	Batch         time.Duration `json:"batch_ns"`          // MGET + pipelined HGETs, mean
+	Concurrent    time.Duration `json:"concurrent_ns"`     // direct + decode across -workers goroutines, mean
+	HGet          time.Duration `json:"hget_ns"`           // n × HGET of the email, hashes only, mean
+	HMGet         time.Duration `json:"hmget_ns"`          // n × HMGET of every hash field + rebuild, mean
+	HGetAll       time.Duration `json:"hgetall_ns"`        // n × HGETALL + rebuild, mean
+
+	Reset   string        `json:"reset,omitempty"`       // server stats reset before insert
+	Misses  int           `json:"misses,omitempty"`      // fetches aimed at absent keys
+	HitAvg  time.Duration `json:"hit_avg_ns,omitempty"`  // direct latency per hit
+	MissAvg time.Duration `json:"miss_avg_ns,omitempty"` // direct latency per miss
+
+	Stats    map[string]PhaseStats `json:"stats,omitempty"`       // per-phase aggregate over -runs passes
+	TimedOut []string              `json:"timed_out,omitempty"`   // phases cut off by -phase-timeout
+	Alloc    map[string]uint64     `json:"alloc_bytes,omitempty"` // client bytes allocated per phase
+
+	Failed    map[string]int `json:"failed,omitempty"`    // records lost to per-record fetch errors, per phase
+	Attempted map[string]int `json:"attempted,omitempty"` // records fetched by the passes that finished, per phase
+
+	Timed []TimedResult `json:"timed,omitempty"` // -duration mode only
+}
+
 func main() {
+	cfg := parseFlags()
+	start := time.Now()
+	handleInterrupts()
+
+	//    With -output csv or json only the result document goes to stdout
+	//    (see resultsOut); everything else printed is moved to stderr
+	if cfg.Output != "table" {
+		os.Stdout = os.Stderr
+	}
+	keyPrefix = "bench:" + cfg.RunID + ":"
+	fmt.Printf("🔖 Run ID %s: keys live under %s*\n", cfg.RunID, keyPrefix)
+	//    With -seed the generated records are reproducible; the run ID
+	//    above was drawn before seeding, so it stays unique
+	if cfg.Seeded {
+		seedRandom(cfg.Seed)
+		fmt.Printf("🎲 Seed %d: generated records repeat across runs\n", cfg.Seed)
+	}
+	if !cfg.NoFlush {
+		fmt.Printf("⚠️  FLUSHDB runs before each size and wipes all of DB %d, including other runs' keys; use -no-flush or -run-id on a shared server\n", cfg.DB)
+	}
+
 	// 1) Connect to Redis
-	rdb := redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
-		DB:   0,
-	})
+	rdb := newClient(cfg, cfg.DB)
 	defer rdb.Close()
 
-	// Track all keys we insert, so cleanup can delete exactly them
-	var insertedKeys []string
+	//    With -check, verify correctness on a tiny dataset and stop there
+	if cfg.Check {
+		if err := runCheck(rdb, cfg); err != nil {
+			log.Fatalf("Check failed: %v", err)
+		}
+		return
+	}
 
-	// Print table header
-	fmt.Println("Redis: pipeline vs Lua for GET + HGET")
-	fmt.Println("Count   | ΔMem (MB) | Direct Fetch   | Pipeline Fetch | Lua Fetch")
-	fmt.Println("--------+-----------+----------------+----------------+-----------")
+	// 2) Run every size and workload. Failures are collected rather than
+	//    fatal, so cleanup always runs before the exit status is set
+	errs := newErrorLog(cfg.QuietErrors)
+	results, aborted := runBenchmark(rdb, cfg, errs)
+	printPoolStats(rdb)
+	errs.printSummary()
 
-	// 2) Loop through each test size
-	for _, n := range sampleCounts {
-		// a) Flush DB before each run to isolate tests
-		if err := rdb.FlushDB(ctx).Err(); err != nil {
-			log.Fatalf("FLUSHDB failed: %v", err)
-		}
+	// 5) Optionally save and compare this run and store it in the results
+	//    history. Every size that completed is kept and compared, even
+	//    when others failed; the exit status below still reports that
+	regression := ""
+	if len(results) > 0 {
+		regression = persistResults(cfg, results)
+	}
 
-		// b) Measure memory before insertion
-		beforeBytes, _ := getMemory(rdb)
-
-		// c) Insert n records under two distinct keys per record:
-		//    - "bench:json:<UUID>" for SET/GET
-		//    - "bench:hash:<UUID>" for HSET/HGET
-		jsonKeys := make([]string, n)
-		hashKeys := make([]string, n)
-		for i := 0; i < n; i++ {
-			rec := generateRecord()
-			jsonKey := "bench:json:" + rec.ID
-			hashKey := "bench:hash:" + rec.ID
-
-			// Store full JSON under jsonKey
-			data, _ := json.Marshal(rec)
-			if err := rdb.Set(ctx, jsonKey, data, 0).Err(); err != nil {
-				log.Fatalf("SET failed for key %s: %v", jsonKey, err)
-			}
-			// Store only the email under hashKey
-			if err := rdb.HSet(ctx, hashKey, "email", rec.Email).Err(); err != nil {
-				log.Fatalf("HSET failed for key %s: %v", hashKey, err)
-			}
-
-			// Track keys for fetch and cleanup
-			jsonKeys[i] = jsonKey
-			hashKeys[i] = hashKey
-			insertedKeys = append(insertedKeys, jsonKey, hashKey)
+	// 6) Exit non-zero if the run was aborted, logged errors or regressed
+	//    past -fail-on-regress, once everything above has been written
+	if cfg.ExitSummary != "" {
+		summary := newRunSummary(results, aborted, errs.total(), regression, time.Since(start))
+		if err := writeRunSummary(cfg.ExitSummary, summary); err != nil {
+			log.Fatalf("%v", err)
 		}
+	}
+	if aborted || errs.total() > 0 || regression != "" {
+		os.Exit(1)
+	}
+}
 
-		// d) Measure memory after insertion and compute delta
-		afterBytes, _ := getMemory(rdb)
-		deltaMB := float64(afterBytes-beforeBytes) / 1024.0 / 1024.0
-
-		// e) Direct fetch: n × (GET + HGET)
-		t0 := time.Now()
-		for i := 0; i < n; i++ {
-			if _, err := rdb.Get(ctx, jsonKeys[i]).Result(); err != nil {
-				log.Fatalf("Direct GET failed: %v", err)
-			}
-			if _, err := rdb.HGet(ctx, hashKeys[i], "email").Result(); err != nil {
-				log.Fatalf("Direct HGET failed: %v", err)
-			}
-		}
-		durDirect := time.Since(t0)
-
-		// f) Pipeline fetch: batch GET + HGET in a single round-trip
-		t1 := time.Now()
-		pipe := rdb.Pipeline()
-		for i := 0; i < n; i++ {
-			pipe.Get(ctx, jsonKeys[i])
-			pipe.HGet(ctx, hashKeys[i], "email")
+// persistResults saves and compares results as the -save-baseline,
+// -compare-baseline, -results-db and -sqlite flags ask. It describes a
+// slowdown past -fail-on-regress, and returns "" when there is none.
+func persistResults(cfg Config, results []BenchResult) (regression string) {
+	if cfg.SaveBaseline != "" {
+		if err := saveBaseline(cfg.SaveBaseline, results); err != nil {
+			log.Fatalf("Saving baseline failed: %v", err)
 		}
-		if _, err := pipe.Exec(ctx); err != nil {
-			log.Fatalf("Pipeline exec failed: %v", err)
+		fmt.Printf("📝 Saved baseline to %s\n", cfg.SaveBaseline)
+	}
+	if cfg.CompareBaseline != "" {
+		worst, err := compareBaseline(cfg.CompareBaseline, results)
+		if err != nil {
+			log.Fatalf("Comparing against baseline failed: %v", err)
 		}
-		durPipe := time.Since(t1)
-
-		// g) Lua fetch: server-side atomic GET + HGET
-		luaScript := redis.NewScript(`
-            local res = {}
-            for i=1,#KEYS do
-                local v = redis.call("GET", KEYS[i])
-                local e = redis.call("HGET", ARGV[i], "email")
-                table.insert(res, {v, e})
-            end
-            return res
-        `)
-		t2 := time.Now()
-		if _, err := luaScript.Run(ctx, rdb, jsonKeys, hashKeys).Result(); err != nil {
-			log.Fatalf("Lua script failed: %v", err)
+		if cfg.FailOnRegress > 0 && worst > cfg.FailOnRegress {
+			regression = fmt.Sprintf("slowest regression %+.1f%% exceeds -fail-on-regress %g%%", worst, cfg.FailOnRegress)
+			fmt.Printf("⛔ %s\n", regression)
 		}
-		durLua := time.Since(t2)
-
-		// h) Print results for this batch size
-		fmt.Printf("%6d | %+9.2f | %14v | %14v | %10v\n",
-			n, deltaMB, durDirect, durPipe, durLua,
-		)
 	}
 
-	// 3) Final cleanup: delete exactly the keys we inserted (no others)
-	if err := deleteInsertedKeys(rdb, insertedKeys); err != nil {
-		log.Fatalf("Final cleanup failed: %v", err)
+	// Append this run to the results history, comparing it against the
+	// previous run stored there, and/or to a SQLite file
+	if cfg.ResultsDB >= 0 {
+		if err := recordResults(cfg, results); err != nil {
+			log.Fatalf("Storing results failed: %v", err)
+		}
+	}
+	if cfg.SQLite != "" {
+		if err := recordSQLite(cfg, results); err != nil {
+			log.Fatalf("Storing results in SQLite failed: %v", err)
+		}
 	}
-	fmt.Println("✅ Cleanup complete: only bench:* keys removed")
+	return regression
 }
 
-// getMemory returns Redis's used_memory (bytes) and used_memory_human.
-func getMemory(rdb *redis.Client) (bytes int64, human string) {
-	info, err := rdb.Info(ctx, "memory").Result()
-	if err != nil {
-		log.Fatalf("INFO memory failed: %v", err)
-	}
+// getMemory returns Redis's used_memory in bytes, summed over every
+// master of a cluster.
+func getMemory(rdb redis.UniversalClient) (bytes int64, err error) {
+	err = forEachMaster(rdb, func(node *redis.Client) error {
+		info, err := node.Info(ctx, "memory").Result()
+		if err != nil {
+			return fmt.Errorf("INFO memory failed: %w", err)
+		}
+		used, ok := parseUsedMemory(info)
+		if !ok {
+			return fmt.Errorf("INFO memory reply has no used_memory field")
+		}
+		bytes += used
+		return nil
+	})
+	return bytes, err
+}
+
+// parseUsedMemory extracts used_memory from an INFO memory reply. The
+// used_memory_* fields that follow it do not match.
+func parseUsedMemory(info string) (used int64, ok bool) {
 	for _, line := range strings.Split(info, "\n") {
-		if strings.HasPrefix(line, "used_memory:") {
-			fmt.Sscanf(line, "used_memory:%d", &bytes)
+		if _, err := fmt.Sscanf(line, "used_memory:%d", &used); err == nil {
+			return used, true
 		}
-		if strings.HasPrefix(line, "used_memory_human:") {
-			parts := strings.SplitN(line, ":", 2)
-			human = strings.TrimSpace(parts[1])
+	}
+	return 0, false
+}
+
+// memorySamples is how many keys of each type sampleMemoryUsage asks
+// MEMORY USAGE about.
+const memorySamples = 100
+
+// sampleMemoryUsage estimates the mean bytes per key with MEMORY USAGE on
+// up to memorySamples keys spread evenly over keys, and returns it with
+// the number of keys that answered. A key that is gone, a 0 reply or an
+// error (e.g. a server without MEMORY USAGE) is left out of the mean, so
+// with no successful sample both results are 0.
+func sampleMemoryUsage(rdb redis.UniversalClient, keys []string) (float64, int) {
+	n := len(keys)
+	if n > memorySamples {
+		n = memorySamples
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	pipe := rdb.Pipeline()
+	cmds := make([]*redis.IntCmd, n)
+	for i := range cmds {
+		cmds[i] = pipe.MemoryUsage(ctx, keys[i*len(keys)/n])
+	}
+	pipe.Exec(ctx) // per-key errors are checked below
+	var total int64
+	ok := 0
+	for _, c := range cmds {
+		if v, err := c.Result(); err == nil && v > 0 {
+			total += v
+			ok++
 		}
 	}
-	return
+	if ok == 0 {
+		return 0, 0
+	}
+	return float64(total) / float64(ok), ok
 }
 
 // deleteInsertedKeys deletes exactly the given keys in batches,
 // ensuring no other keys in Redis are touched.
-func deleteInsertedKeys(rdb *redis.Client, keys []string) error {
+func deleteInsertedKeys(rdb redis.UniversalClient, keys []string) error {
+	_, err := deleteKeys(rdb, keys)
+	return err
+}
+
+// deleteKeys is deleteInsertedKeys that also returns how many keys DEL
+// actually removed, which is fewer than len(keys) when some had expired.
+// It still deletes after an interrupt.
+func deleteKeys(rdb redis.UniversalClient, keys []string) (int64, error) {
+	detachInterrupt()
 	const batchSize = 1000
+	var deleted int64
 	for i := 0; i < len(keys); i += batchSize {
 		end := i + batchSize
 		if end > len(keys) {
 			end = len(keys)
 		}
-		if err := rdb.Del(ctx, keys[i:end]...).Err(); err != nil {
-			return fmt.Errorf("failed deleting keys %d–%d: %w", i, end, err)
+		n, err := delKeys(rdb, keys[i:end])
+		if err != nil {
+			return deleted, &CleanupError{From: i, To: end, Err: err}
 		}
+		deleted += n
 	}
-	return nil
+	return deleted, nil
+}
+
+// delKeys deletes keys with one DEL, or one per hash slot against a
+// cluster, where a DEL must not span slots.
+func delKeys(rdb redis.UniversalClient, keys []string) (int64, error) {
+	if isCluster(rdb) {
+		return countBySlot(rdb, keys, redis.Pipeliner.Del)
+	}
+	return rdb.Del(ctx, keys...).Result()
 }
 
 // generateRecord creates a random Record for testing.
@@ -185,14 +286,56 @@ func randStr(n int) string {
 	letters := "abcdefghijklmnopqrstuvwxyz"
 	buf := make([]byte, n)
 	for i := range buf {
-		idx, _ := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
-		buf[i] = letters[idx.Int64()]
+		buf[i] = letters[randInt(0, len(letters))]
 	}
 	return string(buf)
 }
 
+// chance reports true with probability p.
+func chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	return float64(randInt(0, 1_000_000)) < p*1_000_000
+}
+
 // randInt returns a random int in [min, max).
 func randInt(min, max int) int {
+	if seeded != nil {
+		return seeded.intn(max-min) + min
+	}
 	n, _ := rand.Int(rand.Reader, big.NewInt(int64(max-min)))
 	return int(n.Int64()) + min
 }
+
+// seeded is the -seed source behind randInt and uuid.New; nil means
+// crypto/rand.
+var seeded *lockedRand
+
+// lockedRand is a math/rand source that the concurrent workloads can
+// share.
+type lockedRand struct {
+	mu sync.Mutex
+	r  *mrand.Rand
+}
+
+func (l *lockedRand) intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
+
+// Read fills p from the source, so it can back uuid.SetRand.
+func (l *lockedRand) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Read(p)
+}
+
+// seedRandom makes generated data reproducible: from here on randStr,
+// randInt and uuid.New all draw from a math/rand source seeded with seed,
+// so the same seed yields the same records in the same order.
+func seedRandom(seed int64) {
+	seeded = &lockedRand{r: mrand.New(mrand.NewSource(seed))}
+	uuid.SetRand(seeded)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// newTestRedis starts an in-process miniredis server for one test and
+// returns it with a client connected to it. Both are closed when the test
+// ends.
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, redis.UniversalClient) {
+	t.Helper()
+	m := miniredis.RunT(t)
+	rdb := newClient(Config{Addr: m.Addr()}, 0)
+	rdb.AddHook(infoMemoryHook{})
+	t.Cleanup(func() { rdb.Close() })
+	return m, rdb
+}
+
+// infoMemoryHook answers INFO memory, which miniredis does not support,
+// with a fixed used_memory so code that measures memory can run.
+type infoMemoryHook struct{}
+
+func (infoMemoryHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (infoMemoryHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if c, ok := cmd.(*redis.StringCmd); ok && strings.EqualFold(fmt.Sprint(cmd.Args()...), "infomemory") {
+		c.SetVal("# Memory\r\nused_memory:1048576\r\n")
+		c.SetErr(nil)
+	}
+	return nil
+}
+
+func (infoMemoryHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (infoMemoryHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+// insertTestRecords inserts n generated records and returns their dataset.
+func insertTestRecords(t *testing.T, rdb redis.UniversalClient, n int) dataset {
+	t.Helper()
+	src, err := openRecordSource(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, err := insertRecords(rdb, Config{}, src, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ds
+}
+
+// failHook makes every command named name fail with an injected error
+// after it ran, so error paths can be exercised against miniredis.
+type failHook struct{ name string }
+
+func (h failHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h failHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if cmd.Name() == h.name {
+		cmd.SetErr(errInjected)
+	}
+	return nil
+}
+
+func (h failHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h failHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		h.AfterProcess(ctx, cmd)
+	}
+	return nil
+}
+
+var errInjected = errors.New("injected failure")
+
+func TestSeedReproducesRecords(t *testing.T) {
+	t.Cleanup(func() {
+		seeded = nil
+		uuid.SetRand(nil)
+	})
+	sequence := func(seed int64) []Record {
+		seedRandom(seed)
+		recs := make([]Record, 50)
+		for i := range recs {
+			recs[i] = generateRecord()
+		}
+		return recs
+	}
+
+	a, b := sequence(42), sequence(42)
+	if !reflect.DeepEqual(a, b) {
+		t.Error("two sequences with seed 42 differ")
+	}
+	if reflect.DeepEqual(a, sequence(43)) {
+		t.Error("seeds 42 and 43 produced the same sequence")
+	}
+}
+
+func TestSampleMemoryUsage(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ds := insertTestRecords(t, rdb, 150)
+
+	// At most memorySamples keys are asked, all of which exist
+	avg, n := sampleMemoryUsage(rdb, ds.jsonKeys)
+	if n != memorySamples || avg <= 0 {
+		t.Errorf("sampleMemoryUsage over 150 keys = %v from %d samples, want a mean from %d", avg, n, memorySamples)
+	}
+
+	// Keys that do not exist are left out of the mean
+	keys := append([]string{keyPrefix + "json:absent", keyPrefix + "hash:absent"}, ds.hashKeys[:3]...)
+	avg, n = sampleMemoryUsage(rdb, keys)
+	if n != 3 || avg <= 0 {
+		t.Errorf("sampleMemoryUsage with 2 absent keys = %v from %d samples, want a mean from 3", avg, n)
+	}
+	if avg, n = sampleMemoryUsage(rdb, keys[:2]); avg != 0 || n != 0 {
+		t.Errorf("sampleMemoryUsage of absent keys = %v from %d samples, want 0 from 0", avg, n)
+	}
+}
+
+func TestGenerateRecord(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		rec := generateRecord()
+		if _, err := uuid.Parse(rec.ID); err != nil {
+			t.Errorf("ID %q is not a UUID: %v", rec.ID, err)
+		}
+		if len(rec.Name) != 6 || strings.Trim(rec.Name, "abcdefghijklmnopqrstuvwxyz") != "" {
+			t.Errorf("name %q is not 6 lowercase letters", rec.Name)
+		}
+		local, domain, ok := strings.Cut(rec.Email, "@")
+		if !ok || len(local) != 8 || domain != "example.com" {
+			t.Errorf("email %q is not <8 letters>@example.com", rec.Email)
+		}
+		if rec.Amount < 100 || rec.Amount >= 99999 || rec.Amount != float64(int(rec.Amount)) {
+			t.Errorf("amount %v is not a whole number in [100, 99999)", rec.Amount)
+		}
+	}
+}
+
+func TestParseUsedMemory(t *testing.T) {
+	info := "# Memory\r\nused_memory:1048576\r\nused_memory_human:1.00M\r\n" +
+		"used_memory_rss:2097152\r\nused_memory_peak:3145728\r\n"
+	if used, ok := parseUsedMemory(info); !ok || used != 1048576 {
+		t.Errorf("parseUsedMemory = %d, %v; want 1048576, true", used, ok)
+	}
+	// Only the exact field counts, wherever it appears
+	if used, ok := parseUsedMemory("used_memory_rss:5\nused_memory:7\n"); !ok || used != 7 {
+		t.Errorf("parseUsedMemory = %d, %v; want 7, true", used, ok)
+	}
+	if _, ok := parseUsedMemory("# Memory\r\nused_memory_rss:5\r\n"); ok {
+		t.Error("parseUsedMemory found used_memory in a reply without it")
+	}
+}
+
+func TestGetMemory(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	if used, err := getMemory(rdb); err != nil || used != 1048576 {
+		t.Errorf("getMemory = %d, %v; want the hook's 1048576", used, err)
+	}
+}
+
+func TestFetchStrategiesReturnSameValues(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ds := insertTestRecords(t, rdb, 25)
+
+	direct, err := checkDirect(rdb, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, fetch := range map[string]func(redis.UniversalClient, dataset) ([]fetchedRecord, error){
+		"pipeline": checkPipeline,
+		"lua":      checkLua,
+	} {
+		got, err := fetch(rdb, ds)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, direct) {
+			t.Errorf("%s returned %v, direct %v", name, got, direct)
+		}
+	}
+	for i, r := range direct {
+		if r.json != ds.values[i] || r.email != ds.emails[i] {
+			t.Errorf("record %d: fetched %+v, stored %s / %s", i, r, ds.values[i], ds.emails[i])
+		}
+	}
+}
+
+func TestDeleteInsertedKeysLeavesControlKey(t *testing.T) {
+	m, rdb := newTestRedis(t)
+	ds := insertTestRecords(t, rdb, 30)
+	control := keyPrefix + "control"
+	if err := m.Set(control, "keep"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := deleteInsertedKeys(rdb, ds.distinct); err != nil {
+		t.Fatal(err)
+	}
+	keys := m.Keys()
+	if len(keys) != 1 || keys[0] != control {
+		t.Errorf("keys left after cleanup: %v, want only %s", keys, control)
+	}
+}
+
+func TestSeedJSONKeysReturnsKeysOnError(t *testing.T) {
+	m, rdb := newTestRedis(t)
+	rdb.AddHook(pipelineFailHook{})
+	keys, err := seedJSONKeys(rdb, keyPrefix+"seed:", 5)
+	if err == nil {
+		t.Fatal("seedJSONKeys succeeded with every SET failing")
+	}
+	// The SETs ran before the hook failed the pipeline, so they must be
+	// deletable
+	if len(keys) != 5 || len(m.Keys()) != 5 {
+		t.Fatalf("seedJSONKeys returned %d keys for %d stored, want 5", len(keys), len(m.Keys()))
+	}
+	if err := deleteInsertedKeys(rdb, keys); err != nil {
+		t.Fatal(err)
+	}
+	if left := m.Keys(); len(left) != 0 {
+		t.Errorf("keys left after cleanup: %v", left)
+	}
+}
+
+// pipelineFailHook fails every pipeline after it ran.
+type pipelineFailHook struct{ infoMemoryHook }
+
+func (pipelineFailHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return errInjected
+}
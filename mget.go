@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"  // for formatted I/O
+	"time" // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// runMGetSweep seeds JSON keys and fetches all of them with MGET batches of
+// each -mget-sizes size. Tiny batches pay a round-trip per few keys; huge
+// ones make the server build, and the client buffer, one enormous reply.
+// The dataset holds at least as many keys as the largest batch, so every
+// size is measured over the same keys. The fastest size is reported.
+func runMGetSweep(rdb redis.UniversalClient, cfg Config) error {
+	n := cfg.WorkloadSize
+	for _, size := range cfg.MGetSizes {
+		if size > n {
+			n = size
+		}
+	}
+	keys, err := seedJSONKeys(rdb, keyPrefix+"mget:", n)
+	defer deleteInsertedKeys(rdb, keys)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("MGET batch size (%d JSON keys)\n", n)
+	fmt.Println("Batch  | MGETs  | Total        | Per MGET     | Keys/s")
+	fmt.Println("-------+--------+--------------+--------------+-----------")
+	best, bestDur := 0, time.Duration(0)
+	for _, size := range cfg.MGetSizes {
+		calls := 0
+		t0 := time.Now()
+		for i := 0; i < n; i += size {
+			end := i + size
+			if end > n {
+				end = n
+			}
+			vals, err := rdb.MGet(ctx, keys[i:end]...).Result()
+			if err != nil {
+				return fmt.Errorf("MGET of %d keys failed: %w", end-i, err)
+			}
+			for j, v := range vals {
+				if v == nil {
+					return fmt.Errorf("MGET returned nil for %s", keys[i+j])
+				}
+			}
+			calls++
+		}
+		dur := time.Since(t0)
+		fmt.Printf("%6d | %6d | %12v | %12v | %10.0f\n",
+			size, calls, dur, dur/time.Duration(calls), float64(n)/dur.Seconds())
+		if best == 0 || dur < bestDur {
+			best, bestDur = size, dur
+		}
+	}
+	fmt.Printf("  sweet spot: %d keys per MGET (%v for all %d keys)\n", best, bestDur, n)
+	return nil
+}
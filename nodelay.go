@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"  // for formatted I/O
+	"time" // for durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// runNoDelayCompare runs the direct fetch over -workload-size records on
+// two fresh clients, one with TCP_NODELAY set and one with Nagle's
+// algorithm enabled. Strict request/response traffic mostly escapes Nagle,
+// since each request goes out with nothing unacknowledged in flight; the
+// penalty shows up when a write is split or follows unacknowledged data,
+// where Nagle can hold it back until the peer's delayed ACK.
+func runNoDelayCompare(rdb redis.UniversalClient, cfg Config) error {
+	n := cfg.WorkloadSize
+	ds, err := insertRecords(rdb, cfg, &recordSource{}, n)
+	defer deleteInsertedKeys(rdb, ds.distinct)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("TCP_NODELAY on vs off (direct fetch, %d records)\n", n)
+	var per [2]time.Duration
+	for i, noDelay := range []bool{true, false} {
+		c := cfg
+		c.NoDelay = noDelay
+		client := newClient(c, cfg.DB)
+		if err := client.Ping(ctx).Err(); err != nil {
+			client.Close()
+			return fmt.Errorf("PING failed: %w", err)
+		}
+		d, err := fetchDirect(ctx, client, ds.jsonKeys, ds.hashKeys)
+		client.Close()
+		if err != nil {
+			return err
+		}
+		per[i] = d / time.Duration(n)
+		label := "TCP_NODELAY on "
+		if !noDelay {
+			label = "TCP_NODELAY off"
+		}
+		fmt.Printf("  %s | total %v | %v per record\n", label, d, per[i])
+	}
+	fmt.Printf("  Nagle changes direct fetch by %+v per record (%.2fx)\n",
+		per[1]-per[0], float64(per[1])/float64(per[0]))
+	return nil
+}
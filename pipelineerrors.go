@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt" // for formatted I/O
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// runPipelineErrors demonstrates go-redis's partial-failure semantics for
+// pipelines. A pipeline of GETs gets one HGET aimed at a string key in the
+// middle, which the server rejects with WRONGTYPE. Exec then returns that
+// first error, but the server still ran every other command, and each
+// Cmder carries its own result and error.
+func runPipelineErrors(rdb redis.UniversalClient, cfg Config) error {
+	n := cfg.WorkloadSize
+	keys, err := seedJSONKeys(rdb, keyPrefix+"pipeerr:", n)
+	defer deleteInsertedKeys(rdb, keys)
+	if err != nil {
+		return err
+	}
+
+	bad := n / 2
+	pipe := rdb.Pipeline()
+	for i, key := range keys {
+		if i == bad {
+			pipe.HGet(ctx, key, "email") // WRONGTYPE: key holds a string
+			continue
+		}
+		pipe.Get(ctx, key)
+	}
+	cmds, execErr := pipe.Exec(ctx)
+
+	// Exec's error is only the first failure; inspect every command
+	var ok, failed int
+	for i, cmd := range cmds {
+		if cmd.Err() != nil {
+			failed++
+			continue
+		}
+		// A surviving GET must still carry the value stored at its key
+		if get, isGet := cmd.(*redis.StringCmd); isGet && i != bad && get.Val() == "" {
+			return fmt.Errorf("command %d succeeded but returned no value", i)
+		}
+		ok++
+	}
+
+	fmt.Println("Pipeline partial failure")
+	fmt.Printf("  Exec error: %v\n", execErr)
+	fmt.Printf("  %d/%d commands succeeded despite the error, %d failed\n",
+		ok, len(cmds), failed)
+	if execErr == nil || failed != 1 || ok != n-1 {
+		return fmt.Errorf("unexpected pipeline outcome: exec err %v, %d ok, %d failed", execErr, ok, failed)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"     // for formatted I/O
+	"runtime" // for client-side allocation stats
+	"time"    // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// runPipelineFlush measures how flush granularity affects a pipelined
+// fetch. go-redis buffers every queued command in memory and writes them
+// to the socket only on Exec, with no way to flush part of a pipeline, so
+// the flush interval is modelled as a sequence of Execs of N GETs each.
+// Small N pays more round-trips; large N holds more commands and replies
+// in client memory at once. The fastest N is reported as optimal.
+func runPipelineFlush(rdb redis.UniversalClient, cfg Config) error {
+	n := cfg.WorkloadSize
+	keys, err := seedJSONKeys(rdb, keyPrefix+"flush:", n)
+	defer deleteInsertedKeys(rdb, keys)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Pipeline flush interval (%d GETs)\n", n)
+	fmt.Println("Per Exec | Execs  | Total        | Per key   | Client alloc")
+	fmt.Println("---------+--------+--------------+-----------+-------------")
+	best, bestDur := 0, time.Duration(0)
+	for _, size := range cfg.PipelineFlushSizes {
+		if size > n {
+			size = n
+		}
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		t0 := time.Now()
+		execs := 0
+		for i := 0; i < n; i += size {
+			end := i + size
+			if end > n {
+				end = n
+			}
+			pipe := rdb.Pipeline()
+			for _, key := range keys[i:end] {
+				pipe.Get(ctx, key)
+			}
+			if _, err := pipe.Exec(ctx); err != nil {
+				return fmt.Errorf("pipeline of %d GETs failed: %w", end-i, err)
+			}
+			execs++
+		}
+		dur := time.Since(t0)
+		runtime.ReadMemStats(&after)
+		fmt.Printf("%8d | %6d | %12v | %9v | %9.1f KB\n",
+			size, execs, dur, dur/time.Duration(n),
+			float64(after.TotalAlloc-before.TotalAlloc)/1024.0)
+		if best == 0 || dur < bestDur {
+			best, bestDur = size, dur
+		}
+	}
+	fmt.Printf("  optimal: %d commands per Exec (%v total)\n", best, bestDur)
+	return nil
+}
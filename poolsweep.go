@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"     // for formatted I/O
+	"strings" // for the table header
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// poolKneeShare is how close to its best throughput a pool size must come
+// to count as a strategy's knee.
+const poolKneeShare = 0.95
+
+// runPoolSweep inserts -workload-size records once and fetches them with
+// every selected strategy through a fresh client per -pool-sizes entry,
+// printing records/s per pool size. go-redis defaults PoolSize to 10 per
+// CPU; pipeline and concurrent fetches are the ones that notice. Each
+// client is closed before the next is built, so the sweep holds at most
+// one pool's connections open at a time. The knee reported per strategy
+// is the smallest pool size within poolKneeShare of its best throughput.
+func runPoolSweep(rdb redis.UniversalClient, cfg Config) error {
+	ds, err := insertRecords(rdb, cfg, &recordSource{}, cfg.WorkloadSize)
+	defer deleteInsertedKeys(rdb, ds.distinct)
+	if err != nil {
+		return err
+	}
+
+	strategies := selectedStrategies(cfg)
+	rates := make([][]float64, len(cfg.PoolSizes))
+	for i, size := range cfg.PoolSizes {
+		if rates[i], err = poolSizeRates(cfg, size, strategies, ds); err != nil {
+			return fmt.Errorf("pool size %d: %w", size, err)
+		}
+	}
+
+	fmt.Printf("Pool size sweep (%d records, records/s)\n", len(ds.jsonKeys))
+	var header, bar strings.Builder
+	for _, s := range strategies {
+		fmt.Fprintf(&header, " %10s |", s.name)
+		bar.WriteString("------------+")
+	}
+	fmt.Printf("Pool  |%s\n", header.String())
+	fmt.Printf("------+%s\n", bar.String())
+	for i, size := range cfg.PoolSizes {
+		fmt.Printf("%5d |", size)
+		for _, r := range rates[i] {
+			if r == 0 {
+				fmt.Printf(" %10s |", "-")
+			} else {
+				fmt.Printf(" %10.0f |", r)
+			}
+		}
+		fmt.Println()
+	}
+	for j, s := range strategies {
+		if knee := poolKnee(cfg.PoolSizes, rates, j); knee > 0 {
+			fmt.Printf("  %s: knee at pool size %d\n", s.name, knee)
+		}
+	}
+	return nil
+}
+
+// poolSizeRates measures strategies over ds through a new client whose
+// pool holds size connections, and returns each one's records/s (0 for a
+// phase that timed out).
+func poolSizeRates(cfg Config, size int, strategies []fetchStrategy, ds dataset) ([]float64, error) {
+	cfg.PoolSize = size
+	client := newClient(cfg, cfg.DB)
+	defer client.Close()
+
+	rates := make([]float64, len(strategies))
+	for j, s := range strategies {
+		fn := s.fetch(cfg, ds)
+		if err := warmup(cfg, s.name, fn, client, ds); err != nil {
+			return nil, err
+		}
+		var res BenchResult
+		mean, err := runPhase(cfg, &res, s.name, fn, client, ds)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.name, err)
+		}
+		if mean > 0 {
+			rates[j] = float64(len(ds.jsonKeys)) / mean.Seconds()
+		}
+	}
+	return rates, nil
+}
+
+// poolKnee returns the smallest of sizes at which strategy j reached
+// poolKneeShare of its best rate, or 0 if it never completed.
+func poolKnee(sizes []int, rates [][]float64, j int) int {
+	var best float64
+	for i := range sizes {
+		if rates[i][j] > best {
+			best = rates[i][j]
+		}
+	}
+	knee := 0
+	for i, size := range sizes {
+		if best > 0 && rates[i][j] >= poolKneeShare*best && (knee == 0 || size < knee) {
+			knee = size
+		}
+	}
+	return knee
+}
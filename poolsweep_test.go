@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPoolKnee(t *testing.T) {
+	sizes := []int{1, 10, 50}
+	rates := [][]float64{{100, 0}, {960, 0}, {1000, 0}}
+	if got := poolKnee(sizes, rates, 0); got != 10 {
+		t.Errorf("knee = %d, want 10 (first size within 95%% of the best)", got)
+	}
+	if got := poolKnee(sizes, rates, 1); got != 0 {
+		t.Errorf("knee of a strategy that never completed = %d, want 0", got)
+	}
+}
+
+func TestPoolSweepClosesClients(t *testing.T) {
+	m, rdb := newTestRedis(t)
+	strategies, err := selectStrategies("pipeline,concurrent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Addr: m.Addr(), Runs: 1, Workers: 4, PipeBatch: 8, WorkloadSize: 50,
+		Strategies: strategies, PoolSizes: []int{1, 4}}
+
+	var runErr error
+	out := captureStdout(t, func() { runErr = runPoolSweep(rdb, cfg) })
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+	for _, want := range []string{"pipeline |", "concurrent |", "\n    1 |", "\n    4 |", "knee at pool size"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if keys := m.Keys(); len(keys) != 0 {
+		t.Errorf("%d keys left after the sweep", len(keys))
+	}
+
+	// Only rdb's own connections stay open once every sweep client is
+	// closed; the server notices a closed connection asynchronously
+	open := int(rdb.PoolStats().TotalConns)
+	deadline := time.Now().Add(time.Second)
+	for m.CurrentConnectionCount() > open && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := m.CurrentConnectionCount(); got > open {
+		t.Errorf("%d connections open after the sweep, want at most rdb's %d", got, open)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"  // for formatted I/O
+	"io"   // for the progress writer
+	"os"   // for detecting a terminal on stderr
+	"time" // for the insertion rate
+)
+
+// progressEvery is how many records pass between progress updates.
+const progressEvery = 2000
+
+// progress reports how far a long insertion has got on one stderr line,
+// rewritten in place with \r. A nil *progress reports nothing.
+type progress struct {
+	w     io.Writer
+	total int
+	start time.Time
+}
+
+// newProgress returns a reporter for inserting total records, or nil when
+// progress is off: it is on with -progress, and otherwise only for the
+// table output with stderr on a terminal. It always writes to stderr, so
+// -output csv or json on stdout stays clean.
+func newProgress(cfg Config, total int) *progress {
+	if !cfg.Progress && !(cfg.Output == "table" && isTerminal(os.Stderr)) {
+		return nil
+	}
+	return &progress{w: os.Stderr, total: total, start: time.Now()}
+}
+
+// isTerminal reports whether f is a character device such as a TTY.
+func isTerminal(f *os.File) bool {
+	st, err := f.Stat()
+	return err == nil && st.Mode()&os.ModeCharDevice != 0
+}
+
+// update reports done records every progressEvery records.
+func (p *progress) update(done int) {
+	if p == nil || done == 0 || done%progressEvery != 0 {
+		return
+	}
+	fmt.Fprintf(p.w, "\r⏳ inserted %d/%d", done, p.total)
+}
+
+// finish ends the progress line with the number of records inserted and
+// the rate they were inserted at.
+func (p *progress) finish(done int) {
+	if p == nil {
+		return
+	}
+	elapsed := time.Since(p.start)
+	fmt.Fprintf(p.w, "\r⏳ inserted %d/%d in %v (%.0f rec/s)\n",
+		done, p.total, elapsed.Round(time.Millisecond), float64(done)/elapsed.Seconds())
+}
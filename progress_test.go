@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressLines(t *testing.T) {
+	var buf bytes.Buffer
+	p := &progress{w: &buf, total: 5000, start: time.Now()}
+	for i := 0; i < 5000; i++ {
+		p.update(i)
+	}
+	p.finish(5000)
+
+	want := []string{"", "⏳ inserted 2000/5000", "⏳ inserted 4000/5000", "⏳ inserted 5000/5000 in "}
+	got := strings.Split(buf.String(), "\r")
+	if len(got) != len(want) {
+		t.Fatalf("progress wrote %q, want %d \\r-separated updates", buf.String(), len(want)-1)
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("update %d = %q, want prefix %q", i, got[i], want[i])
+		}
+	}
+	if last := got[len(got)-1]; !strings.HasSuffix(last, " rec/s)\n") {
+		t.Errorf("final update %q lacks the insertion rate", last)
+	}
+}
+
+func TestProgressOffForMachineOutput(t *testing.T) {
+	for _, output := range []string{"csv", "json"} {
+		if p := newProgress(Config{Output: output}, 10); p != nil {
+			t.Errorf("-output %s without -progress got a progress reporter", output)
+		}
+	}
+	if p := newProgress(Config{Output: "json", Progress: true}, 10); p == nil {
+		t.Error("-progress did not force a progress reporter")
+	}
+	var p *progress // a nil reporter is a no-op
+	p.update(progressEvery)
+	p.finish(10)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"  // for formatted I/O
+	"time" // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// runRandomAccess benchmarks the sampling commands on a hash and a set of
+// cfg.WorkloadSize members each. A positive count returns distinct
+// elements; a negative count may repeat them and always returns |count|.
+func runRandomAccess(rdb redis.UniversalClient, cfg Config) error {
+	n := cfg.WorkloadSize
+	hashKey, setKey := keyPrefix+"rand:hash", keyPrefix+"rand:set"
+	defer deleteInsertedKeys(rdb, []string{hashKey, setKey})
+
+	// Populate one hash (id → email) and one set (ids) in a single pipeline
+	pipe := rdb.Pipeline()
+	for i := 0; i < n; i++ {
+		rec := generateRecord()
+		pipe.HSet(ctx, hashKey, rec.ID, rec.Email)
+		pipe.SAdd(ctx, setKey, rec.ID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("populating random-access keys failed: %w", err)
+	}
+
+	hasHRand, err := versionAtLeast(rdb, "6.2")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Random sampling")
+	fmt.Println("Command       | Count  | Calls  | Total          | Per call")
+	fmt.Println("--------------+--------+--------+----------------+-----------")
+	c := cfg.RandomCount
+	for _, count := range []int{c, -c} {
+		if hasHRand {
+			dur, err := timeSampling(n, n, count, func() ([]string, error) {
+				return rdb.HRandField(ctx, hashKey, count, false).Result()
+			})
+			if err != nil {
+				return fmt.Errorf("HRANDFIELD: %w", err)
+			}
+			printSampling("HRANDFIELD", count, n, dur)
+		}
+		dur, err := timeSampling(n, n, count, func() ([]string, error) {
+			return rdb.SRandMemberN(ctx, setKey, int64(count)).Result()
+		})
+		if err != nil {
+			return fmt.Errorf("SRANDMEMBER: %w", err)
+		}
+		printSampling("SRANDMEMBER", count, n, dur)
+	}
+	if !hasHRand {
+		fmt.Println("⏭  HRANDFIELD skipped: requires Redis >= 6.2")
+	}
+	return nil
+}
+
+// timeSampling calls sample `calls` times and checks each reply has the
+// length the count's sign promises: exactly |count| when negative, and
+// |count| distinct elements (capped by population) when positive.
+func timeSampling(calls, population, count int, sample func() ([]string, error)) (time.Duration, error) {
+	want := count
+	if want < 0 {
+		want = -want
+	} else if want > population {
+		want = population
+	}
+	t0 := time.Now()
+	for i := 0; i < calls; i++ {
+		got, err := sample()
+		if err != nil {
+			return 0, err
+		}
+		if len(got) != want {
+			return 0, fmt.Errorf("count %d returned %d elements, want %d", count, len(got), want)
+		}
+	}
+	return time.Since(t0), nil
+}
+
+// printSampling prints one row of the random-sampling table.
+func printSampling(cmd string, count, calls int, dur time.Duration) {
+	fmt.Printf("%-13s | %+6d | %6d | %14v | %v\n",
+		cmd, count, calls, dur, dur/time.Duration(calls))
+}
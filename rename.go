@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json" // for marshaling records
+	"fmt"           // for formatted I/O
+	"time"          // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// runRename models the stage-then-promote write: each record is written to
+// a temp key and RENAMEd onto its final key. A second batch is then staged
+// and promoted with RENAMENX, first onto the existing final keys, where
+// every rename must be refused and the old value kept, and then onto free
+// keys, where every rename must succeed.
+func runRename(rdb redis.UniversalClient, cfg Config) error {
+	n := cfg.WorkloadSize
+	var keys []string
+	defer func() { deleteInsertedKeys(rdb, keys) }()
+
+	// stage writes a fresh record under a temp key per slot and returns
+	// the temp keys with their values
+	stage := func(tag string) ([]string, []string, error) {
+		temps := make([]string, n)
+		vals := make([]string, n)
+		pipe := rdb.Pipeline()
+		for i := range temps {
+			rec := generateRecord()
+			data, _ := json.Marshal(rec)
+			temps[i] = keyPrefix + fmt.Sprintf("rename:tmp:%s:%s", tag, rec.ID)
+			vals[i] = string(data)
+			pipe.Set(ctx, temps[i], data, 0)
+		}
+		keys = append(keys, temps...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, nil, fmt.Errorf("staging temp keys failed: %w", err)
+		}
+		return temps, vals, nil
+	}
+	finals := make([]string, n)
+	free := make([]string, n)
+	for i := range finals {
+		finals[i] = keyPrefix + fmt.Sprintf("rename:final:%d", i)
+		free[i] = keyPrefix + fmt.Sprintf("rename:free:%d", i)
+	}
+	keys = append(keys, finals...)
+	keys = append(keys, free...)
+
+	// a) RENAME temp → final
+	temps, vals, err := stage("a")
+	if err != nil {
+		return err
+	}
+	t0 := time.Now()
+	for i := range temps {
+		if err := rdb.Rename(ctx, temps[i], finals[i]).Err(); err != nil {
+			return fmt.Errorf("RENAME %s failed: %w", temps[i], err)
+		}
+	}
+	durRename := time.Since(t0)
+
+	// b) RENAMENX onto the now existing final keys: all refused
+	temps, _, err = stage("b")
+	if err != nil {
+		return err
+	}
+	refused := 0
+	t1 := time.Now()
+	for i := range temps {
+		ok, err := rdb.RenameNX(ctx, temps[i], finals[i]).Result()
+		if err != nil {
+			return fmt.Errorf("RENAMENX %s failed: %w", temps[i], err)
+		}
+		if !ok {
+			refused++
+		}
+	}
+	durRefused := time.Since(t1)
+	for i, key := range finals {
+		got, err := rdb.Get(ctx, key).Result()
+		if err != nil {
+			return fmt.Errorf("GET %s failed: %w", key, err)
+		}
+		if got != vals[i] {
+			return fmt.Errorf("RENAMENX overwrote existing key %s", key)
+		}
+	}
+
+	// c) RENAMENX onto free keys: all promoted
+	promoted := 0
+	t2 := time.Now()
+	for i := range temps {
+		ok, err := rdb.RenameNX(ctx, temps[i], free[i]).Result()
+		if err != nil {
+			return fmt.Errorf("RENAMENX %s failed: %w", temps[i], err)
+		}
+		if ok {
+			promoted++
+		}
+	}
+	durPromoted := time.Since(t2)
+
+	fmt.Printf("Stage-then-promote (%d keys)\n", n)
+	fmt.Printf("  RENAME             | %12v | %.0f renames/s\n", durRename, float64(n)/durRename.Seconds())
+	fmt.Printf("  RENAMENX (exists)  | %12v | %.0f renames/s | %d/%d refused, targets unchanged\n",
+		durRefused, float64(n)/durRefused.Seconds(), refused, n)
+	fmt.Printf("  RENAMENX (free)    | %12v | %.0f renames/s | %d/%d promoted\n",
+		durPromoted, float64(n)/durPromoted.Seconds(), promoted, n)
+	if refused != n || promoted != n {
+		return fmt.Errorf("RENAMENX refused %d and promoted %d of %d", refused, promoted, n)
+	}
+	return nil
+}
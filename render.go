@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/csv"  // for -output csv
+	"encoding/json" // for -output json
+	"fmt"           // for unknown formats
+	"io"            // for the results writer
+	"os"            // for writing to stdout
+	"strconv"       // for CSV fields
+	"time"          // for the document timestamp
+)
+
+// resultsOut receives the -output csv or json document. It is the real
+// stdout: with those formats main points os.Stdout at stderr, so the
+// progress and workload output printed everywhere else cannot end up
+// interleaved with the document.
+var resultsOut io.Writer = os.Stdout
+
+// render writes the run to resultsOut in a machine-readable format. "json"
+// gives one RunRecord document, the same layout the results history
+// stores, with the concurrency sweep under "sweep". "csv" gives a plain
+// CSV table: a header row and one row per size with durations in integer
+// nanoseconds, or in -duration mode one row per size and strategy. It
+// carries no schema or client version (the JSON document does) and no
+// sweep, which goes to -sweep-output instead. The "table" format is
+// streamed while the run progresses, so render has nothing left to do
+// for it.
+func render(results []BenchResult, sweep []SweepPoint, format string) error {
+	switch format {
+	case "table":
+		return nil
+	case "json":
+		enc := json.NewEncoder(resultsOut)
+		enc.SetIndent("", "  ")
+		return enc.Encode(RunRecord{
+			SchemaVersion: resultsSchemaVersion,
+			Timestamp:     time.Now().UTC(),
+			ClientVersion: clientVersion(),
+			Results:       results,
+			Sweep:         sweep,
+		})
+	case "csv":
+		w := csv.NewWriter(resultsOut)
+		if len(results) > 0 && results[0].Timed != nil {
+			writeTimedCSV(w, results)
+		} else {
+			writeResultsCSV(w, results)
+		}
+		w.Flush()
+		return w.Error()
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// writeResultsCSV writes one row per size of the counted fetch passes.
+func writeResultsCSV(w *csv.Writer, results []BenchResult) {
+	w.Write([]string{"count", "delta_mb", "direct_ns", "pipeline_ns", "lua_ns", "batch_ns", "concurrent_ns",
+		"write_direct_ns", "write_pipeline_ns", "write_lua_ns", "encoding", "json_bytes", "hash_bytes",
+		"hget_ns", "hmget_ns", "hgetall_ns"})
+	for _, r := range results {
+		w.Write([]string{
+			strconv.Itoa(r.Count),
+			strconv.FormatFloat(r.DeltaMB, 'f', 4, 64),
+			strconv.FormatInt(int64(r.Direct), 10),
+			strconv.FormatInt(int64(r.Pipeline), 10),
+			strconv.FormatInt(int64(r.Lua), 10),
+			strconv.FormatInt(int64(r.Batch), 10),
+			strconv.FormatInt(int64(r.Concurrent), 10),
+			strconv.FormatInt(int64(r.WriteDirect), 10),
+			strconv.FormatInt(int64(r.WritePipeline), 10),
+			strconv.FormatInt(int64(r.WriteLua), 10),
+			r.Encoding,
+			strconv.FormatFloat(r.JSONBytes, 'f', 1, 64),
+			strconv.FormatFloat(r.HashBytes, 'f', 1, 64),
+			strconv.FormatInt(int64(r.HGet), 10),
+			strconv.FormatInt(int64(r.HMGet), 10),
+			strconv.FormatInt(int64(r.HGetAll), 10),
+		})
+	}
+}
+
+// writeTimedCSV writes one row per size and strategy in -duration mode,
+// where the counted-pass columns are all zero.
+func writeTimedCSV(w *csv.Writer, results []BenchResult) {
+	w.Write([]string{"count", "delta_mb", "strategy", "ops", "records", "elapsed_ns",
+		"p50_ns", "p99_ns", "p999_ns", "failed"})
+	for _, r := range results {
+		for _, t := range r.Timed {
+			w.Write([]string{
+				strconv.Itoa(r.Count),
+				strconv.FormatFloat(r.DeltaMB, 'f', 4, 64),
+				t.Strategy,
+				strconv.Itoa(t.Ops),
+				strconv.Itoa(t.Records),
+				strconv.FormatInt(int64(t.Elapsed), 10),
+				strconv.FormatInt(int64(t.P50), 10),
+				strconv.FormatInt(int64(t.P99), 10),
+				strconv.FormatInt(int64(t.P999), 10),
+				strconv.Itoa(t.Failed),
+			})
+		}
+	}
+}
+
+// writeSweepFile writes points as CSV to path, the -sweep-output file.
+func writeSweepFile(path string, points []SweepPoint) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	writeSweepCSV(w, points)
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeSweepCSV writes one row per concurrency level and strategy.
+func writeSweepCSV(w *csv.Writer, points []SweepPoint) {
+	w.Write([]string{"workers", "strategy", "records", "elapsed_ns", "records_per_sec", "p99_ns"})
+	for _, p := range points {
+		w.Write([]string{
+			strconv.Itoa(p.Workers), p.Strategy, strconv.Itoa(p.Records),
+			strconv.FormatInt(int64(p.Elapsed), 10),
+			strconv.FormatFloat(p.Throughput, 'f', 0, 64),
+			strconv.FormatInt(int64(p.P99), 10),
+		})
+	}
+}
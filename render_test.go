@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderJSONDocument(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(w io.Writer) { resultsOut = w }(resultsOut)
+	resultsOut = &buf
+
+	results := []BenchResult{{Count: 10, Direct: time.Millisecond}}
+	sweep := []SweepPoint{{Workers: 2, Strategy: "direct", Records: 10}}
+	if err := render(results, sweep, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var doc RunRecord
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("stdout is not one JSON document: %v\n%s", err, buf.String())
+	}
+	if doc.SchemaVersion != resultsSchemaVersion || doc.ClientVersion == "" {
+		t.Errorf("document metadata = %d %q", doc.SchemaVersion, doc.ClientVersion)
+	}
+	if len(doc.Results) != 1 || doc.Results[0].Direct != time.Millisecond || len(doc.Sweep) != 1 {
+		t.Errorf("document = %+v", doc)
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []BenchResult
+		header  string
+		rows    int
+	}{
+		{"counted", []BenchResult{{Count: 10, Direct: 5}, {Count: 100, Direct: 50}}, "count", 2},
+		{"duration", []BenchResult{{Count: 10, Timed: []TimedResult{
+			{Strategy: "direct", Ops: 3}, {Strategy: "pipeline", Ops: 1}, {Strategy: "lua", Ops: 1},
+		}}}, "count", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			defer func(w io.Writer) { resultsOut = w }(resultsOut)
+			resultsOut = &buf
+			// A sweep never lands in the results CSV
+			sweep := []SweepPoint{{Workers: 2, Strategy: "direct", Records: 10}}
+			if err := render(tt.results, sweep, "csv"); err != nil {
+				t.Fatal(err)
+			}
+			rows, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+			if err != nil {
+				t.Fatalf("default csv.Reader: %v\n%s", err, buf.String())
+			}
+			if len(rows) != tt.rows+1 || rows[0][0] != tt.header {
+				t.Errorf("got %d rows %v, want a header and %d rows", len(rows), rows, tt.rows)
+			}
+			if tt.name == "duration" && rows[1][2] != "direct" {
+				t.Errorf("duration rows = %v, want one per strategy", rows)
+			}
+		})
+	}
+}
+
+func TestWriteSweepFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sweep.csv")
+	points := []SweepPoint{{Workers: 1, Strategy: "direct", Records: 10}, {Workers: 2, Strategy: "lua", Records: 10}}
+	if err := writeSweepFile(path, points); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0][0] != "workers" || rows[2][1] != "lua" {
+		t.Errorf("sweep file rows = %v, want a header and 2 points", rows)
+	}
+}
+
+func TestCSVRunWithSweep(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	saved := sampleCounts
+	t.Cleanup(func() { sampleCounts = saved })
+	sampleCounts = []int{10}
+	var buf bytes.Buffer
+	defer func(w io.Writer) { resultsOut = w }(resultsOut)
+	resultsOut = &buf
+
+	path := filepath.Join(t.TempDir(), "sweep.csv")
+	cfg := Config{Runs: 1, Workers: 2, PipeBatch: 4, Cleanup: "tracked", Output: "csv",
+		WorkloadSize: 20, ConcurrencySweep: 2, SweepOutput: path}
+	errs := newErrorLog(10)
+	captureStdout(t, func() { runBenchmark(rdb, cfg, errs) })
+	if errs.total() > 0 {
+		t.Fatalf("%d errors", errs.total())
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("default csv.Reader: %v\n%s", err, buf.String())
+	}
+	if len(rows) != 2 || rows[0][0] != "count" || rows[1][0] != "10" {
+		t.Errorf("results CSV = %v, want the header and size 10 only", rows)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sweep, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil || len(sweep) < 2 || sweep[0][0] != "workers" {
+		t.Errorf("sweep file = %v (%v), want a header and points", sweep, err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context" // for the OnConnect hook
+	"fmt"     // for formatted I/O
+	"strings" // for parsing INFO and errors
+	"time"    // for the replication wait
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// newReplicaClient connects to the read replica at cfg.ReplicaAddr. Every
+// new connection issues READONLY, which a cluster replica needs before it
+// will serve reads for its master's slots; a standalone replica rejects the
+// command as cluster-only and serves reads anyway, so that error is ignored.
+func newReplicaClient(cfg Config) *redis.Client {
+	opt := clientOptions(cfg, cfg.ReplicaAddr, cfg.DB)
+	setName := opt.OnConnect
+	opt.OnConnect = func(ctx context.Context, cn *redis.Conn) error {
+		if setName != nil {
+			if err := setName(ctx, cn); err != nil {
+				return err
+			}
+		}
+		err := cn.ReadOnly(ctx).Err()
+		if err != nil && strings.Contains(err.Error(), "cluster support disabled") {
+			return nil
+		}
+		return err
+	}
+	return redis.NewClient(opt)
+}
+
+// checkReplica confirms the replica really is one and that it refuses
+// writes, then returns a description of the node for the table header.
+func checkReplica(replica redis.UniversalClient, addr string) (string, error) {
+	info, err := replica.Info(ctx, "replication").Result()
+	if err != nil {
+		return "", fmt.Errorf("INFO replication on %s failed: %w", addr, err)
+	}
+	role := "unknown"
+	for _, line := range strings.Split(info, "\n") {
+		if strings.HasPrefix(line, "role:") {
+			role = strings.TrimSpace(strings.TrimPrefix(line, "role:"))
+		}
+	}
+	if role != "slave" {
+		return "", fmt.Errorf("-replica-addr %s has role %q, not a replica", addr, role)
+	}
+
+	// Writes must go to the master; a replica should reject this probe
+	probe := keyPrefix + "replica:probe"
+	if err := replica.Set(ctx, probe, "1", 0).Err(); err == nil {
+		replica.Del(ctx, probe)
+		fmt.Printf("⚠️  replica %s accepted a write (replica-read-only no); writes still go to the master\n", addr)
+	} else if !strings.HasPrefix(err.Error(), "READONLY") {
+		return "", fmt.Errorf("write probe on replica %s failed: %w", addr, err)
+	}
+	return fmt.Sprintf("replica %s (role %s)", addr, role), nil
+}
+
+// waitForReplica blocks until at least one replica has acknowledged every
+// write made so far on master, so reads on the replica see the dataset.
+func waitForReplica(master redis.UniversalClient) error {
+	acked, err := master.Do(ctx, "WAIT", 1, (10 * time.Second).Milliseconds()).Int64()
+	if err != nil {
+		return fmt.Errorf("WAIT failed: %w", err)
+	}
+	if acked < 1 {
+		return fmt.Errorf("no replica acknowledged the dataset within 10s")
+	}
+	return nil
+}
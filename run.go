@@ -0,0 +1,353 @@
+package main
+
+import (
+	"errors"  // for the memory-cap sentinel
+	"fmt"     // for formatted I/O
+	"log"     // for logging fatal setup errors
+	"sort"    // for a stable failure report
+	"strings" // for joining failure reports
+	"time"    // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// errMemoryCap is returned by runSize when used_memory after insertion is
+// over -max-memory-mb; the run stops before the next, larger size.
+var errMemoryCap = errors.New("used_memory over -max-memory-mb")
+
+// benchRun holds what the per-size work shares across sizes.
+type benchRun struct {
+	cfg    Config
+	rdb    redis.UniversalClient // writes, cleanup and INFO
+	reader redis.UniversalClient // fetches; rdb unless -replica-addr is set
+	src    *recordSource
+	sink   MetricSink
+
+	trackKeys    bool     // keep insertedKeys for cleanup or the keyslot report
+	insertedKeys []string // every key inserted so far
+	prevKeys     []string // previous size's keys, deleted before the next with -no-flush
+}
+
+// runBenchmark runs every size, then the optional workloads, and returns
+// the per-size results and whether -max-memory-mb or an interrupt stopped
+// it early.
+// Failures after setup are collected in errs instead of exiting, and the
+// final cleanup is deferred, so it runs however the benchmark ends and
+// the caller can set the exit status once the keys are gone.
+func runBenchmark(rdb redis.UniversalClient, cfg Config, errs *errorLog) (results []BenchResult, aborted bool) {
+	// Fetches read from -replica-addr when set; writes stay on rdb
+	reader, readNode := rdb, "master "+cfg.Addr
+	if cfg.ReplicaAddr != "" {
+		reader = newReplicaClient(cfg)
+		defer reader.Close()
+		node, err := checkReplica(reader, cfg.ReplicaAddr)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		readNode = node
+	}
+
+	// Records come from the generator or a -dataset-import file
+	src, err := openRecordSource(cfg)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer src.Close()
+
+	// Results are reported through a MetricSink; the table is the default.
+	// With -output csv or json nothing streams and render prints the
+	// collected results once the loop is done.
+	var sink MetricSink = NopSink{}
+	if cfg.Output == "table" {
+		sink = newTableSink(cfg, readNode)
+	}
+
+	// Track all keys we insert, so cleanup can delete exactly them; with
+	// -cleanup scan they are only kept for the keyslot report
+	b := &benchRun{
+		cfg:       cfg,
+		rdb:       rdb,
+		reader:    reader,
+		src:       src,
+		sink:      sink,
+		trackKeys: cfg.Cleanup == "tracked" || cfg.KeySlotReport,
+	}
+	defer b.cleanup(errs)
+
+	// 2) Loop through each test size. A failed size is logged; with
+	//    -continue-on-error the next size still runs
+	for _, n := range sampleCounts {
+		res, err := b.runSize(n)
+		if errors.Is(err, errMemoryCap) {
+			aborted = true
+			break
+		}
+		//    An interrupt drops this size, finished or not; the deferred
+		//    cleanup still deletes every key tracked so far, including
+		//    those of a partial insert
+		if interrupted() {
+			errs.add(fmt.Sprintf("size %d", n), errInterrupted)
+			aborted = true
+			break
+		}
+		if err != nil {
+			errs.add(fmt.Sprintf("size %d", n), err)
+			if !cfg.ContinueOnError {
+				break
+			}
+			continue
+		}
+		if failed := res.failures(); len(failed) > 0 {
+			errs.add(fmt.Sprintf("size %d", n), fmt.Errorf("%s", strings.Join(failed, ", ")))
+		}
+		results = append(results, res)
+	}
+
+	//    and, with -recommend, turn the table into a pick per size
+	if cfg.Recommend {
+		printRecommendations(cfg, results)
+	}
+
+	// 3) Optional workloads; each one deletes the keys it created. With
+	//    -continue-on-error a failure is logged and the next one still
+	//    runs; an interrupt skips the rest
+	stopped := aborted
+	for _, w := range workloads(cfg) {
+		if !w.enabled || stopped || interrupted() {
+			continue
+		}
+		if err := w.run(rdb, cfg); err != nil {
+			errs.add(w.name+" workload", err)
+			stopped = !cfg.ContinueOnError
+		}
+	}
+
+	//    The concurrency sweep's points go into the -output json document
+	//    or the -sweep-output CSV file, so it runs outside the workload list
+	var sweep []SweepPoint
+	if cfg.ConcurrencySweep > 0 && !stopped && !interrupted() {
+		points, err := runConcurrencySweep(rdb, cfg)
+		switch {
+		case err != nil:
+			errs.add("concurrency-sweep workload", err)
+		case cfg.Output == "json":
+			sweep = points
+		case cfg.SweepOutput != "":
+			if err := writeSweepFile(cfg.SweepOutput, points); err != nil {
+				errs.add("sweep output", err)
+			}
+		default:
+			printSweep(points)
+		}
+	}
+
+	if err := render(results, sweep, cfg.Output); err != nil {
+		errs.add("render", err)
+	}
+
+	if cfg.KeySlotReport && !aborted {
+		if err := reportKeySlots(rdb, b.insertedKeys); err != nil {
+			errs.add("keyslot report", err)
+		}
+	}
+
+	if err := src.Close(); err != nil {
+		errs.add("dataset", err)
+	}
+	return results, aborted
+}
+
+// runSize benchmarks one sample size: it inserts n records, times the
+// write and fetch strategies against them and reports the result through
+// the sink. It returns errMemoryCap when the insert pushed used_memory
+// over -max-memory-mb.
+func (b *benchRun) runSize(n int) (BenchResult, error) {
+	cfg, rdb, reader := b.cfg, b.rdb, b.reader
+
+	// a) Flush DB (on every master of a cluster) before each run to
+	//    isolate tests, or with -no-flush delete only the previous size's
+	//    keys
+	if cfg.NoFlush {
+		if err := deleteInsertedKeys(rdb, b.prevKeys); err != nil {
+			return BenchResult{}, fmt.Errorf("cleanup before size %d failed: %w", n, err)
+		}
+	} else if err := forEachMaster(rdb, func(node *redis.Client) error {
+		return node.FlushDB(ctx).Err()
+	}); err != nil {
+		return BenchResult{}, fmt.Errorf("FLUSHDB failed: %w", err)
+	}
+	//    and optionally reset server stats so they cover this size only
+	reset, err := resetServerStats(rdb, cfg)
+	if err != nil {
+		return BenchResult{}, err
+	}
+
+	// b) Measure memory before insertion
+	beforeBytes, err := getMemory(rdb)
+	if err != nil {
+		return BenchResult{}, err
+	}
+
+	// c) Insert n records under two distinct keys per record:
+	//    - "bench:<run ID>:json:{<UUID>}" for SET/GET
+	//    - "bench:<run ID>:hash:{<UUID>}" for HSET/HGET
+	tInsert := time.Now()
+	ds, err := insertRecords(rdb, cfg, b.src, n)
+	durInsert := time.Since(tInsert)
+	if b.trackKeys {
+		b.insertedKeys = append(b.insertedKeys, ds.distinct...)
+	}
+	b.prevKeys = ds.distinct
+	if err != nil {
+		return BenchResult{}, err
+	}
+	distinct := len(ds.distinct) / 2
+
+	// d) Measure memory after insertion and compute delta
+	afterBytes, err := getMemory(rdb)
+	if err != nil {
+		return BenchResult{}, err
+	}
+	deltaMB := float64(afterBytes-beforeBytes) / 1024.0 / 1024.0
+	//    and split it by data model with MEMORY USAGE on a sample of each
+	jsonBytes, _ := sampleMemoryUsage(rdb, ds.jsonKeys)
+	hashBytes, _ := sampleMemoryUsage(rdb, ds.hashKeys)
+
+	//    and stop before the next, larger size if we are over the cap
+	if usedMB := float64(afterBytes) / 1024.0 / 1024.0; cfg.MaxMemoryMB > 0 && usedMB > cfg.MaxMemoryMB {
+		fmt.Printf("⛔ used_memory %.2f MB exceeds -max-memory-mb %.2f at size %d; aborting\n",
+			usedMB, cfg.MaxMemoryMB, n)
+		return BenchResult{}, errMemoryCap
+	}
+
+	//    Time the same writes again directly, through a pipeline and
+	//    through a script. They rewrite the keys just created with the
+	//    same values, so memory and the tracked keys are unchanged
+	durWriteDirect, err := writeDirect(ctx, rdb, ds)
+	if err != nil {
+		return BenchResult{}, err
+	}
+	durWritePipe, err := writePipeline(ctx, rdb, ds)
+	if err != nil {
+		return BenchResult{}, err
+	}
+	durWriteLua, err := writeLua(ctx, rdb, ds)
+	if err != nil {
+		return BenchResult{}, err
+	}
+
+	//    Reads on a replica must wait until it has the whole dataset
+	if reader != rdb {
+		if err := waitForReplica(rdb); err != nil {
+			return BenchResult{}, err
+		}
+	}
+
+	//    With -fetch-miss-rate, that fraction of fetches targets keys
+	//    that were never inserted
+	fetch, misses := ds.withMisses(cfg.FetchMissRate)
+
+	res := BenchResult{
+		Count:         n,
+		Distinct:      distinct,
+		DeltaMB:       deltaMB,
+		Encoding:      storedEncoding(cfg),
+		JSONBytes:     jsonBytes,
+		HashBytes:     hashBytes,
+		Insert:        durInsert,
+		WriteDirect:   durWriteDirect,
+		WritePipeline: durWritePipe,
+		WriteLua:      durWriteLua,
+		Reset:         reset,
+		Misses:        misses,
+	}
+	b.sink.OnInsertDone(res)
+
+	// e)–l) The -strategies selected from fetchStrategies
+	strategies := selectedStrategies(cfg)
+
+	//    -warmup untimed passes of every strategy prime the connection
+	//    pool and the server's caches before anything is measured
+	for _, s := range strategies {
+		if err := warmup(cfg, s.name, s.fetch(cfg, ds), reader, fetch); err != nil {
+			return res, err
+		}
+	}
+
+	//    With -duration, each strategy runs for a fixed wall-clock
+	//    window instead of the counted passes below
+	if cfg.Duration > 0 {
+		if res.Timed, err = runTimedFetches(cfg, reader, fetch); err != nil {
+			return res, err
+		}
+	} else {
+		for _, s := range strategies {
+			if *s.result(&res), err = runPhase(cfg, &res, s.name, s.fetch(cfg, ds), reader, fetch); err != nil {
+				return res, err
+			}
+		}
+
+		//    and compare the cost of a miss with that of a hit
+		if misses > 0 {
+			if res.HitAvg, res.MissAvg, err = measureHitMiss(ctx, reader, fetch); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	// j) Report results for this batch size
+	b.sink.OnFetchDone(res)
+
+	// k) With -ttl-verify, wait out the TTL and check nothing is left
+	if cfg.TTLVerify {
+		if err := verifyExpired(rdb, ds.distinct, cfg.TTL); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// cleanup is the final cleanup: delete exactly the keys we inserted (no
+// others), or sweep this run's prefix with SCAN. A failure is added to
+// errs, so the run still exits non-zero.
+func (b *benchRun) cleanup(errs *errorLog) {
+	// 4) Final cleanup
+	if b.cfg.Cleanup == "scan" {
+		deleted, err := cleanupBySCAN(b.rdb, keyPrefix+"*")
+		if err != nil {
+			errs.add("final cleanup", fmt.Errorf("after %d keys: %w", deleted, err))
+			return
+		}
+		fmt.Printf("✅ Cleanup complete: %d %s* keys removed by SCAN\n", deleted, keyPrefix)
+		return
+	}
+	deleted, err := deleteKeys(b.rdb, b.insertedKeys)
+	if err != nil {
+		errs.add("final cleanup", err)
+		return
+	}
+	//    With -ttl some tracked keys may be gone already, so say how many
+	//    DEL actually found (earlier sizes are also gone unless -no-flush)
+	if b.cfg.TTL > 0 {
+		fmt.Printf("✅ Cleanup complete: only %s* keys removed (%d of %d tracked keys still existed)\n",
+			keyPrefix, deleted, len(b.insertedKeys))
+	} else {
+		fmt.Printf("✅ Cleanup complete: only %s* keys removed\n", keyPrefix)
+	}
+}
+
+// failures returns the phases of r that lost records to per-record fetch
+// errors, in name order, each as "phase: failed/total".
+func (r BenchResult) failures() []string {
+	var out []string
+	for phase, failed := range r.Failed {
+		out = append(out, fmt.Sprintf("%s: %d/%d fetches failed", phase, failed, r.Attempted[phase]))
+	}
+	for _, t := range r.Timed {
+		if t.Failed > 0 {
+			out = append(out, fmt.Sprintf("%s: %d/%d fetches failed", t.Strategy, t.Failed, t.Records+t.Failed))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
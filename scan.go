@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"     // for formatted I/O
+	"strings" // for the prefix guard
+	"time"    // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// scanCount is the COUNT hint passed to every SCAN call.
+const scanCount = 100
+
+// scanKeys iterates the whole keyspace, on every master of a cluster, with
+// SCAN MATCH pattern and returns every matching key and the number of SCAN
+// calls it took. SCAN may return a key more than once; callers that care
+// must dedupe.
+func scanKeys(rdb redis.UniversalClient, pattern string) (keys []string, calls int, err error) {
+	err = forEachMaster(rdb, func(node *redis.Client) error {
+		var cursor uint64
+		for {
+			batch, next, err := node.Scan(ctx, cursor, pattern, scanCount).Result()
+			if err != nil {
+				return fmt.Errorf("SCAN %d MATCH %s failed: %w", cursor, pattern, err)
+			}
+			calls++
+			keys = append(keys, batch...)
+			if cursor = next; cursor == 0 {
+				return nil
+			}
+		}
+	})
+	return keys, calls, err
+}
+
+// cleanupCount is the COUNT hint for cleanupBySCAN, larger than scanCount
+// since each batch is deleted in one pipelined round-trip.
+const cleanupCount = 1000
+
+// cleanupBySCAN deletes every key matching pattern, on every master of a
+// cluster, one SCAN batch at a time, with the DELs for each batch
+// pipelined, and returns how many keys were deleted. The pattern must stay
+// under the "bench:" prefix so the sweep can never reach keys the
+// benchmark did not write. A key SCAN returns twice is only counted once,
+// since the second DEL removes nothing.
+func cleanupBySCAN(rdb redis.UniversalClient, pattern string) (int64, error) {
+	if !strings.HasPrefix(pattern, "bench:") {
+		return 0, fmt.Errorf("refusing to SCAN-delete %q outside the bench: prefix", pattern)
+	}
+	detachInterrupt()
+	var deleted int64
+	err := forEachMaster(rdb, func(node *redis.Client) error {
+		var cursor uint64
+		for {
+			batch, next, err := node.Scan(ctx, cursor, pattern, cleanupCount).Result()
+			if err != nil {
+				return fmt.Errorf("SCAN %d MATCH %s failed: %w", cursor, pattern, err)
+			}
+			if len(batch) > 0 {
+				pipe := node.Pipeline()
+				dels := make([]*redis.IntCmd, len(batch))
+				for i, key := range batch {
+					dels[i] = pipe.Del(ctx, key)
+				}
+				if _, err := pipe.Exec(ctx); err != nil {
+					return fmt.Errorf("DEL of %d scanned keys failed: %w", len(batch), err)
+				}
+				for _, d := range dels {
+					deleted += d.Val()
+				}
+			}
+			if cursor = next; cursor == 0 {
+				return nil
+			}
+		}
+	})
+	return deleted, err
+}
+
+// runPrefixScan fills a keyspace of 10 × -workload-size keys spread over
+// 100 two-digit buckets, then runs a full SCAN with MATCH patterns of
+// falling selectivity. MATCH is applied after keys are read from the
+// table, so every pattern examines the whole keyspace and a selective
+// pattern mostly pays for keys it throws away.
+func runPrefixScan(rdb redis.UniversalClient, cfg Config) error {
+	n := 10 * cfg.WorkloadSize
+	keys := make([]string, n)
+	pipe := rdb.Pipeline()
+	for i := range keys {
+		keys[i] = keyPrefix + fmt.Sprintf("scan:%02d:%d", i%100, i)
+		pipe.Set(ctx, keys[i], "x", 0)
+	}
+	defer deleteInsertedKeys(rdb, keys)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("seeding %sscan:* failed: %w", keyPrefix, err)
+	}
+	examined, err := rdb.DBSize(ctx).Result()
+	if err != nil {
+		return fmt.Errorf("DBSIZE failed: %w", err)
+	}
+
+	fmt.Printf("SCAN MATCH selectivity (%d keys examined per full scan, COUNT %d)\n", examined, scanCount)
+	fmt.Println("Pattern              | Returned | Selectivity | Calls | Time         | Examined/s | Returned/s")
+	fmt.Println("---------------------+----------+-------------+-------+--------------+------------+-----------")
+	for _, pattern := range []string{
+		keyPrefix + "scan:*",      // every bench:scan key
+		keyPrefix + "scan:0*",     // 10 of 100 buckets
+		keyPrefix + "scan:00:*",   // 1 bucket
+		keyPrefix + "scan:00:1?0", // a handful of keys
+		keyPrefix + "scan:none:*", // nothing
+	} {
+		t0 := time.Now()
+		found, calls, err := scanKeys(rdb, pattern)
+		dur := time.Since(t0)
+		if err != nil {
+			return err
+		}
+		returned := len(dedupe(found))
+		fmt.Printf("%-20s | %8d | %10.2f%% | %5d | %12v | %10.0f | %10.0f\n",
+			pattern, returned, 100*float64(returned)/float64(examined), calls, dur,
+			float64(examined)/dur.Seconds(), float64(returned)/dur.Seconds())
+	}
+	return nil
+}
+
+// dedupe returns keys with repeats removed, keeping first occurrences.
+func dedupe(keys []string) []string {
+	seen := make(map[string]bool, len(keys))
+	out := keys[:0:0]
+	for _, k := range keys {
+		if !seen[k] {
+			seen[k] = true
+			out = append(out, k)
+		}
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"     // for formatted I/O
+	"strings" // for joining the breakdown
+	"time"    // for durations
+)
+
+// strategyScore is one strategy's weighted score at one size.
+type strategyScore struct {
+	name    string
+	perRec  time.Duration // latency per record fetched
+	alloc   uint64        // client bytes allocated by the phase
+	latency float64       // best per-record latency / this one, in (0,1]
+	memory  float64       // least allocation / this one, in (0,1]
+	total   float64       // weighted sum of latency and memory
+}
+
+// scoreSize scores every strategy that completed at size r. Each metric is
+// normalised against the best strategy at that size (best = 1), so the
+// weights express relative importance regardless of units. Throughput is
+// the inverse of per-record latency and so is covered by the latency term.
+// It uses only the stored results and makes no Redis calls.
+func scoreSize(cfg Config, r BenchResult) []strategyScore {
+	var out []strategyScore
+	if r.Timed != nil {
+		for _, t := range r.Timed {
+			if t.Records > 0 {
+				out = append(out, strategyScore{name: t.Strategy, perRec: t.Elapsed / time.Duration(t.Records)})
+			}
+		}
+	} else {
+		for _, s := range fetchStrategies {
+			d := *s.result(&r)
+			if s.hashOnly || r.timedOut(s.name) || d == 0 || r.Count == 0 {
+				continue
+			}
+			out = append(out, strategyScore{name: s.name, perRec: d / time.Duration(r.Count), alloc: r.Alloc[s.name]})
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+
+	bestLat, bestAlloc := out[0].perRec, out[0].alloc
+	for _, s := range out {
+		if s.perRec < bestLat {
+			bestLat = s.perRec
+		}
+		if s.alloc < bestAlloc {
+			bestAlloc = s.alloc
+		}
+	}
+	wsum := cfg.WeightLatency + cfg.WeightMemory
+	for i := range out {
+		s := &out[i]
+		s.latency = float64(bestLat) / float64(s.perRec)
+		s.memory = 1 // no allocation data (e.g. -duration mode) is neutral
+		if s.alloc > 0 {
+			s.memory = float64(bestAlloc) / float64(s.alloc)
+		}
+		s.total = (cfg.WeightLatency*s.latency + cfg.WeightMemory*s.memory) / wsum
+	}
+	return out
+}
+
+// printRecommendations prints the best-scoring strategy for each size with
+// the score breakdown of every candidate.
+func printRecommendations(cfg Config, results []BenchResult) {
+	fmt.Printf("Recommendation (weights: latency %.2f, memory %.2f; 1.00 = best on every metric)\n",
+		cfg.WeightLatency, cfg.WeightMemory)
+	for _, r := range results {
+		scores := scoreSize(cfg, r)
+		if len(scores) == 0 {
+			fmt.Printf("%6d | no completed strategies\n", r.Count)
+			continue
+		}
+		best := scores[0]
+		var parts []string
+		for _, s := range scores {
+			if s.total > best.total {
+				best = s
+			}
+			parts = append(parts, fmt.Sprintf("%s %.2f (lat %.2f @ %v/rec, mem %.2f @ %d B)",
+				s.name, s.total, s.latency, s.perRec, s.memory, s.alloc))
+		}
+		fmt.Printf("%6d | ➜ %-8s | %s\n", r.Count, best.name, strings.Join(parts, " · "))
+	}
+}
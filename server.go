@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"     // for formatted errors
+	"strconv" // for parsing version components
+	"strings" // for parsing INFO output
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// serverVersion returns the redis_version reported by INFO server.
+func serverVersion(rdb redis.UniversalClient) (string, error) {
+	info, err := rdb.Info(ctx, "server").Result()
+	if err != nil {
+		return "", fmt.Errorf("INFO server failed: %w", err)
+	}
+	for _, line := range strings.Split(info, "\n") {
+		if strings.HasPrefix(line, "redis_version:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "redis_version:")), nil
+		}
+	}
+	return "", fmt.Errorf("INFO server has no redis_version")
+}
+
+// versionAtLeast reports whether the server runs at least version want
+// (e.g. "6.2"), comparing dotted components numerically.
+func versionAtLeast(rdb redis.UniversalClient, want string) (bool, error) {
+	have, err := serverVersion(rdb)
+	if err != nil {
+		return false, err
+	}
+	return compareVersions(have, want) >= 0, nil
+}
+
+// compareVersions returns -1, 0 or 1 as a is older than, equal to, or newer
+// than b. Missing components count as zero, so "7" == "7.0.0".
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// resetServerStats clears the server-side statistics selected in cfg, on
+// every master of a cluster, so INFO commandstats/latencystats and LATENCY
+// reports cover only the work done afterwards. It returns a short
+// description of what was reset, or "" if nothing was requested.
+func resetServerStats(rdb redis.UniversalClient, cfg Config) (string, error) {
+	var done []string
+	if cfg.ResetStats {
+		if err := forEachMaster(rdb, func(node *redis.Client) error {
+			return node.ConfigResetStat(ctx).Err()
+		}); err != nil {
+			return "", fmt.Errorf("CONFIG RESETSTAT failed: %w", err)
+		}
+		done = append(done, "CONFIG RESETSTAT")
+	}
+	if cfg.ResetLatency {
+		if err := forEachMaster(rdb, func(node *redis.Client) error {
+			return node.Do(ctx, "LATENCY", "RESET").Err()
+		}); err != nil {
+			return "", fmt.Errorf("LATENCY RESET failed: %w", err)
+		}
+		done = append(done, "LATENCY RESET")
+	}
+	return strings.Join(done, " + "), nil
+}
+
+// clusterEnabled reports whether the server runs with cluster mode on.
+func clusterEnabled(rdb redis.UniversalClient) (bool, error) {
+	info, err := rdb.Info(ctx, "cluster").Result()
+	if err != nil {
+		return false, fmt.Errorf("INFO cluster failed: %w", err)
+	}
+	return strings.Contains(info, "cluster_enabled:1"), nil
+}
+
+// getConfig returns the current value of a server configuration parameter.
+func getConfig(rdb redis.UniversalClient, param string) (string, error) {
+	vals, err := rdb.ConfigGet(ctx, param).Result()
+	if err != nil {
+		return "", fmt.Errorf("CONFIG GET %s failed: %w", param, err)
+	}
+	if len(vals) < 2 {
+		return "", fmt.Errorf("CONFIG GET %s: unknown parameter", param)
+	}
+	v, _ := vals[1].(string)
+	return v, nil
+}
+
+// setConfig changes a server configuration parameter and returns a func
+// that puts the previous value back. Callers should defer the restore so
+// the server is left as it was found.
+func setConfig(rdb redis.UniversalClient, param, value string) (restore func() error, err error) {
+	old, err := getConfig(rdb, param)
+	if err != nil {
+		return nil, err
+	}
+	if err := rdb.ConfigSet(ctx, param, value).Err(); err != nil {
+		return nil, fmt.Errorf("CONFIG SET %s %s failed: %w", param, value, err)
+	}
+	return func() error {
+		if err := rdb.ConfigSet(ctx, param, old).Err(); err != nil {
+			return fmt.Errorf("restoring %s to %s failed: %w", param, old, err)
+		}
+		return nil
+	}, nil
+}
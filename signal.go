@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"   // for the interrupt context
+	"errors"    // for the interrupt sentinel
+	"fmt"       // for formatted I/O
+	"os"        // for os.Interrupt and stderr
+	"os/signal" // for catching SIGINT and SIGTERM
+	"syscall"   // for SIGTERM
+)
+
+// errInterrupted is reported for the size a SIGINT or SIGTERM cut short.
+var errInterrupted = errors.New("interrupted")
+
+// interrupt is done once the run has been interrupted. It stays done after
+// cleanup detaches ctx from it, so loops can still tell they must stop.
+var interrupt = context.Background()
+
+// handleInterrupts derives ctx from SIGINT and SIGTERM, so a Ctrl-C
+// cancels the Redis call in flight and the run stops at the next record or
+// size, then deletes the keys it inserted and exits non-zero. Only the
+// first signal is caught: a second one kills the process as usual.
+func handleInterrupts() {
+	sig, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	ctx, interrupt = sig, sig
+	go func() {
+		<-sig.Done()
+		stop()
+		fmt.Fprintln(os.Stderr, "\n⚠️  Interrupted: cleaning up this run's keys before exiting; interrupt again to quit now")
+	}()
+}
+
+// interrupted reports whether the run has been interrupted.
+func interrupted() bool {
+	return interrupt.Err() != nil
+}
+
+// detachInterrupt lets cleanup run after an interrupt: ctx keeps its values
+// but no longer carries the cancellation, so cleanup commands go through.
+func detachInterrupt() {
+	if ctx.Err() != nil {
+		ctx = context.WithoutCancel(ctx)
+	}
+}
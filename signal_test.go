@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// interruptAfterHook cancels the run once n HSETs have been sent, as a
+// Ctrl-C part way through an insert would.
+type interruptAfterHook struct {
+	n      *int
+	cancel context.CancelFunc
+}
+
+func (h interruptAfterHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h interruptAfterHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if cmd.Name() == "hset" {
+		if *h.n--; *h.n == 0 {
+			h.cancel()
+		}
+	}
+	return nil
+}
+
+func (h interruptAfterHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h interruptAfterHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+// withInterrupt replaces ctx and interrupt with a context the test can
+// cancel, restoring both when the test ends.
+func withInterrupt(t *testing.T) context.CancelFunc {
+	t.Helper()
+	savedCtx, savedInterrupt := ctx, interrupt
+	t.Cleanup(func() { ctx, interrupt = savedCtx, savedInterrupt })
+	c, cancel := context.WithCancel(context.Background())
+	ctx, interrupt = c, c
+	return cancel
+}
+
+func TestInterruptCleansUpPartialSize(t *testing.T) {
+	m, rdb := newTestRedis(t)
+	saved := sampleCounts
+	t.Cleanup(func() { sampleCounts = saved })
+	sampleCounts = []int{10, 100, 1000}
+
+	// Size 10 completes (its insert and direct write send one HSET per
+	// record; pipelined and scripted ones are not seen here), then the
+	// interrupt lands 50 records into size 100
+	n := 10*2 + 50
+	rdb.AddHook(interruptAfterHook{n: &n, cancel: withInterrupt(t)})
+	control := "other:control"
+	if err := m.Set(control, "keep"); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := newErrorLog(10)
+	var results []BenchResult
+	var aborted bool
+	captureStdout(t, func() {
+		results, aborted = runBenchmark(rdb, Config{Runs: 1, Workers: 2, PipeBatch: 4, NoFlush: true,
+			Cleanup: "tracked", Output: "table"}, errs)
+	})
+	if !aborted {
+		t.Error("an interrupted run was not reported as aborted")
+	}
+	if len(results) != 1 || results[0].Count != 10 {
+		t.Errorf("results for %d sizes, want only the completed size 10", len(results))
+	}
+	if errs.total() == 0 {
+		t.Error("the interrupted size was not logged")
+	}
+	keys := m.Keys()
+	if len(keys) != 1 || keys[0] != control {
+		t.Errorf("keys left after an interrupted run: %d (%v...), want only %s", len(keys), keys[:min(len(keys), 3)], control)
+	}
+}
+
+func TestHandleInterruptsCatchesSIGINT(t *testing.T) {
+	savedCtx, savedInterrupt := ctx, interrupt
+	t.Cleanup(func() { ctx, interrupt = savedCtx, savedInterrupt })
+
+	handleInterrupts()
+	if interrupted() {
+		t.Fatal("interrupted before any signal")
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !interrupted() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !interrupted() || ctx.Err() == nil {
+		t.Fatal("SIGINT did not cancel ctx")
+	}
+
+	// Cleanup detaches ctx, but the run still knows it was interrupted
+	detachInterrupt()
+	if ctx.Err() != nil || !interrupted() {
+		t.Errorf("after detachInterrupt: ctx.Err() = %v, interrupted() = %v", ctx.Err(), interrupted())
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"     // for formatted I/O
+	"strings" // for joining phase names
+	"time"    // for durations
+)
+
+// MetricSink receives a size's measurements at phase boundaries, so results
+// can be fed into any reporting or telemetry system without touching the
+// benchmark itself.
+type MetricSink interface {
+	// OnInsertDone is called once a size's dataset is in place, with the
+	// insertion and memory fields of r filled in.
+	OnInsertDone(r BenchResult)
+	// OnFetchDone is called after every fetch strategy ran for a size,
+	// with r complete.
+	OnFetchDone(r BenchResult)
+}
+
+// NopSink is a MetricSink that ignores everything.
+type NopSink struct{}
+
+func (NopSink) OnInsertDone(BenchResult) {}
+func (NopSink) OnFetchDone(BenchResult)  {}
+
+// tableSink prints the human-readable results table to stdout.
+type tableSink struct {
+	cfg Config
+}
+
+// newTableSink prints the title and column header and returns the sink.
+// readNode names the server the fetch phases read from.
+func newTableSink(cfg Config, readNode string) *tableSink {
+	fmt.Printf("Redis: pipeline vs Lua for GET + HGET (go-redis %s)\n", clientVersion())
+	fmt.Printf("Reads served by %s; records stored as %s\n", readNode, storedEncoding(cfg))
+	if cfg.Duration > 0 {
+		printTimedHeader()
+	} else {
+		if cfg.Normalize {
+			fmt.Println("(speedup relative to direct fetch; higher is faster)")
+		}
+		if cfg.Runs > 1 {
+			fmt.Printf("(fetches: mean ± stddev over %d runs)\n", cfg.Runs)
+		}
+		//    One fetch column per selected strategy
+		w := fetchCellWidth(cfg)
+		var titles, bars strings.Builder
+		for _, s := range selectedStrategies(cfg) {
+			fmt.Fprintf(&titles, " %-*s |", w, s.title(cfg))
+			bars.WriteString(strings.Repeat("-", w+2) + "+")
+		}
+		fmt.Printf("Count   | ΔMem (MB) | JSON B/rec | Hash B/rec |%s Direct Write   | Pipeline Write | Lua Write\n", titles.String())
+		fmt.Printf("--------+-----------+------------+------------+%s----------------+----------------+-----------\n", bars.String())
+	}
+	return &tableSink{cfg: cfg}
+}
+
+// fetchCellWidth is the width of a fetch column, which grows to fit
+// mean ± stddev with -runs and the p99 with -show-p99.
+func fetchCellWidth(cfg Config) int {
+	switch {
+	case cfg.Normalize || cfg.Runs == 1:
+		return 14
+	case cfg.ShowP99:
+		return 36
+	}
+	return 22
+}
+
+func (t *tableSink) OnInsertDone(BenchResult) {}
+
+func (t *tableSink) OnFetchDone(r BenchResult) {
+	if r.Timed != nil {
+		printTimed(r.Count, r.DeltaMB, r.Timed)
+	} else {
+		w := fetchCellWidth(t.cfg)
+		var cells strings.Builder
+		for _, s := range selectedStrategies(t.cfg) {
+			fmt.Fprintf(&cells, " %*s |", w, t.cell(r, s.name, *s.result(&r)))
+		}
+		fmt.Printf("%6d | %+9.2f | %10s | %10s |%s %14v | %14v | %v\n",
+			r.Count, r.DeltaMB, bytesCell(r.JSONBytes), bytesCell(r.HashBytes),
+			cells.String(), r.WriteDirect, r.WritePipeline, r.WriteLua,
+		)
+	}
+	if len(r.TimedOut) > 0 {
+		fmt.Printf("       ↳ timed out after %v: %s\n",
+			t.cfg.PhaseTimeout, strings.Join(r.TimedOut, ", "))
+	}
+	for _, f := range r.failures() {
+		fmt.Printf("       ↳ %s\n", f)
+	}
+	if r.Reset != "" {
+		fmt.Printf("       ↳ %s before insert\n", r.Reset)
+	}
+	if r.Misses > 0 && r.Timed == nil {
+		fmt.Printf("       ↳ %d/%d misses, direct hit %v vs miss %v per record\n",
+			r.Misses, r.Count, r.HitAvg, r.MissAvg)
+	}
+	if t.cfg.CollisionRate > 0 {
+		fmt.Printf("       ↳ %d/%d distinct records, insert %v (%.0f rec/s)\n",
+			r.Distinct, r.Count, r.Insert, float64(r.Count)/r.Insert.Seconds())
+	}
+}
+
+// bytesCell formats a sampled MEMORY USAGE mean, or "-" without samples.
+func bytesCell(b float64) string {
+	if b == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f", b)
+}
+
+// cell formats a strategy's duration for the table, or "timed out" if the
+// phase hit -phase-timeout. With -runs above 1 it shows mean ± stddev, plus
+// the p99 with -show-p99. With -normalize-by-baseline it shows the speedup
+// of the mean over the direct strategy instead (direct/d, so direct is
+// 1.00x).
+func (t *tableSink) cell(r BenchResult, phase string, d time.Duration) string {
+	if r.timedOut(phase) {
+		return "timed out"
+	}
+	if !t.cfg.Normalize {
+		st, ok := r.Stats[phase]
+		if !ok || st.Runs < 2 {
+			return d.String()
+		}
+		c := fmt.Sprintf("%v ± %v", st.Mean.Round(time.Microsecond), st.Stddev.Round(time.Microsecond))
+		if t.cfg.ShowP99 {
+			c += fmt.Sprintf(" (p99 %v)", st.P99.Round(time.Microsecond))
+		}
+		return c
+	}
+	if r.timedOut("direct") || r.Direct == 0 || d == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.2fx", float64(r.Direct)/float64(d))
+}
+
+// timedOut reports whether phase hit -phase-timeout.
+func (r BenchResult) timedOut(phase string) bool {
+	for _, p := range r.TimedOut {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+// recordingSink is a MetricSink that keeps every call in order.
+type recordingSink struct {
+	calls   []string
+	results []BenchResult
+}
+
+func (s *recordingSink) OnInsertDone(r BenchResult) {
+	s.calls = append(s.calls, "insert")
+	s.results = append(s.results, r)
+}
+
+func (s *recordingSink) OnFetchDone(r BenchResult) {
+	s.calls = append(s.calls, "fetch")
+	s.results = append(s.results, r)
+}
+
+func TestMetricSinkPhases(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	cfg := Config{Runs: 2, Workers: 2, PipeBatch: 4}
+	src, err := openRecordSource(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &recordingSink{}
+	b := &benchRun{cfg: cfg, rdb: rdb, reader: rdb, src: src, sink: sink, trackKeys: true}
+	if _, err := b.runSize(10); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.calls) != 2 || sink.calls[0] != "insert" || sink.calls[1] != "fetch" {
+		t.Fatalf("calls = %v, want [insert fetch]", sink.calls)
+	}
+	ins, fetch := sink.results[0], sink.results[1]
+
+	// OnInsertDone gets the insertion and memory fields, but no fetches yet
+	if ins.Count != 10 || ins.Distinct != 10 || ins.Insert <= 0 || ins.WriteDirect <= 0 {
+		t.Errorf("OnInsertDone got %+v, want the insertion fields filled in", ins)
+	}
+	if ins.Direct != 0 || ins.Stats != nil {
+		t.Errorf("OnInsertDone got fetch results already: %+v", ins)
+	}
+
+	// OnFetchDone gets the complete result
+	if fetch.Count != 10 || fetch.Insert != ins.Insert {
+		t.Errorf("OnFetchDone lost the insertion fields: %+v", fetch)
+	}
+	for _, phase := range []string{"direct", "pipeline", "lua", "batch", "concurrent"} {
+		if st := fetch.Stats[phase]; st.Runs != cfg.Runs || st.Mean <= 0 {
+			t.Errorf("OnFetchDone stats[%s] = %+v, want %d runs", phase, st, cfg.Runs)
+		}
+	}
+}
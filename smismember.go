@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"     // for formatted I/O
+	"strconv" // for member names
+	"time"    // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// smisMemberBatch is how many members one membership check asks about,
+// like the tags or permissions a request needs checked at once.
+const smisMemberBatch = 100
+
+// runSMIsMember builds a set of -workload-size members and checks twice as
+// many candidates, half of them present, in batches of smisMemberBatch:
+// once with one SMISMEMBER per batch and once with one SISMEMBER per
+// member. Both must agree on every answer.
+func runSMIsMember(rdb redis.UniversalClient, cfg Config) error {
+	ok, err := versionAtLeast(rdb, "6.2")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("⏭  SMISMEMBER workload skipped: requires Redis >= 6.2")
+		return nil
+	}
+
+	n := cfg.WorkloadSize
+	key := keyPrefix + "smismember:set"
+	defer deleteInsertedKeys(rdb, []string{key})
+	members := make([]interface{}, n)
+	for i := range members {
+		members[i] = "m" + strconv.Itoa(i)
+	}
+	if err := rdb.SAdd(ctx, key, members...).Err(); err != nil {
+		return fmt.Errorf("SADD %s failed: %w", key, err)
+	}
+	// Even candidates are members, odd ones are not
+	candidates := make([]interface{}, 2*n)
+	for i := range candidates {
+		if i%2 == 0 {
+			candidates[i] = "m" + strconv.Itoa(i/2)
+		} else {
+			candidates[i] = "x" + strconv.Itoa(i/2)
+		}
+	}
+
+	// a) One SMISMEMBER per batch
+	batched := make([]bool, 0, len(candidates))
+	t0 := time.Now()
+	for i := 0; i < len(candidates); i += smisMemberBatch {
+		end := i + smisMemberBatch
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+		res, err := rdb.SMIsMember(ctx, key, candidates[i:end]...).Result()
+		if err != nil {
+			return fmt.Errorf("SMISMEMBER %s failed: %w", key, err)
+		}
+		batched = append(batched, res...)
+	}
+	durBatch := time.Since(t0)
+
+	// b) One SISMEMBER per candidate
+	t1 := time.Now()
+	for i, c := range candidates {
+		is, err := rdb.SIsMember(ctx, key, c).Result()
+		if err != nil {
+			return fmt.Errorf("SISMEMBER %s failed: %w", key, err)
+		}
+		if is != batched[i] || is != (i%2 == 0) {
+			return fmt.Errorf("membership of %v: SISMEMBER %v, SMISMEMBER %v", c, is, batched[i])
+		}
+	}
+	durSingle := time.Since(t1)
+
+	fmt.Printf("Set membership checks (%d members, %d checks, %d per batch)\n",
+		n, len(candidates), smisMemberBatch)
+	fmt.Printf("  SMISMEMBER | %12v | %.0f checks/s\n", durBatch, float64(len(candidates))/durBatch.Seconds())
+	fmt.Printf("  SISMEMBER  | %12v | %.0f checks/s\n", durSingle, float64(len(candidates))/durSingle.Seconds())
+	fmt.Printf("  batching is %.1fx faster; both agree on all %d answers\n",
+		float64(durSingle)/float64(durBatch), len(candidates))
+	return nil
+}
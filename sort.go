@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"     // for formatted I/O
+	"sort"    // for checking reply order
+	"strconv" // for amounts as list elements
+	"time"    // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// sortCalls is how many times each SORT variant runs per list length.
+const sortCalls = 10
+
+// sortVariant is one SORT command shape timed by runSort.
+type sortVariant struct {
+	name string        // label printed in the table
+	args []interface{} // full command, as passed to Do
+}
+
+// runSort fills a list with record amounts and times SORT in its common
+// shapes as the list grows: a numeric sort with LIMIT, and BY an external
+// weight key with GET of an external name key. SORT_RO, the read-only
+// variant that replicas accept, repeats both on Redis >= 7.0. Every
+// variant still sorts the whole list, so cost grows with N log N even
+// though LIMIT returns only 10 elements.
+func runSort(rdb redis.UniversalClient, cfg Config) error {
+	hasRO, err := versionAtLeast(rdb, "7.0")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("SORT on lists")
+	fmt.Println("Length  | Variant                           | Per call")
+	fmt.Println("--------+-----------------------------------+-----------")
+	for _, n := range cfg.SortSizes {
+		if err := runSortLength(rdb, n, hasRO); err != nil {
+			return err
+		}
+	}
+	if !hasRO {
+		fmt.Println("⏭  SORT_RO skipped: requires Redis >= 7.0")
+	}
+	return nil
+}
+
+// runSortLength fills a list of n amounts, prints a row per variant, and
+// deletes the list and its weight and name keys before returning.
+func runSortLength(rdb redis.UniversalClient, n int, hasRO bool) error {
+	keys, err := populateSortList(rdb, n)
+	defer deleteInsertedKeys(rdb, keys)
+	if err != nil {
+		return err
+	}
+	variants := []sortVariant{
+		{"SORT LIMIT 0 10", sortArgs("SORT", false)},
+		{"SORT BY w:* LIMIT 0 10 GET name:*", sortArgs("SORT", true)},
+	}
+	if hasRO {
+		variants = append(variants,
+			sortVariant{"SORT_RO LIMIT 0 10", sortArgs("SORT_RO", false)},
+			sortVariant{"SORT_RO BY w:* LIMIT 0 10 GET name:*", sortArgs("SORT_RO", true)},
+		)
+	}
+	for _, v := range variants {
+		var reply []string
+		t0 := time.Now()
+		for i := 0; i < sortCalls; i++ {
+			if reply, err = rdb.Do(ctx, v.args...).StringSlice(); err != nil {
+				return fmt.Errorf("%s failed: %w", v.name, err)
+			}
+		}
+		dur := time.Since(t0) / sortCalls
+		if err := checkSortReply(v.args, reply, n); err != nil {
+			return fmt.Errorf("%s: %w", v.name, err)
+		}
+		fmt.Printf("%7d | %-33s | %v\n", n, v.name, dur)
+	}
+	return nil
+}
+
+// populateSortList writes n record amounts to bench:sort:list, plus a
+// weight and a name key per distinct amount for BY/GET, and returns every
+// key it created.
+func populateSortList(rdb redis.UniversalClient, n int) ([]string, error) {
+	keys := []string{keyPrefix + "sort:list"}
+	seen := make(map[string]bool, n)
+	pipe := rdb.Pipeline()
+	for i := 0; i < n; i++ {
+		rec := generateRecord()
+		amount := strconv.FormatFloat(rec.Amount, 'f', -1, 64)
+		pipe.RPush(ctx, keyPrefix+"sort:list", amount)
+		if !seen[amount] {
+			seen[amount] = true
+			w, name := keyPrefix+"sort:w:"+amount, keyPrefix+"sort:name:"+amount
+			pipe.Set(ctx, w, randInt(0, 1_000_000), 0)
+			pipe.Set(ctx, name, rec.Name, 0)
+			keys = append(keys, w, name)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return keys, fmt.Errorf("populating sort list failed: %w", err)
+	}
+	return keys, nil
+}
+
+// sortArgs builds a SORT or SORT_RO command on bench:sort:list with
+// LIMIT 0 10, optionally sorting BY the weight keys and GETting names.
+func sortArgs(cmd string, byGet bool) []interface{} {
+	args := []interface{}{cmd, keyPrefix + "sort:list"}
+	if byGet {
+		args = append(args, "BY", keyPrefix+"sort:w:*")
+	}
+	args = append(args, "LIMIT", 0, 10)
+	if byGet {
+		args = append(args, "GET", keyPrefix+"sort:name:*")
+	}
+	return args
+}
+
+// checkSortReply verifies a reply has min(10, n) elements and, for a
+// plain numeric sort, that they come back in ascending order.
+func checkSortReply(args []interface{}, reply []string, n int) error {
+	want := 10
+	if n < want {
+		want = n
+	}
+	if len(reply) != want {
+		return fmt.Errorf("returned %d elements, want %d", len(reply), want)
+	}
+	if len(args) > 2 && args[2] == "BY" {
+		return nil
+	}
+	nums := make([]float64, len(reply))
+	for i, s := range reply {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("element %q is not numeric", s)
+		}
+		nums[i] = v
+	}
+	if !sort.Float64sAreSorted(nums) {
+		return fmt.Errorf("reply %v is not ascending", reply)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"database/sql" // for the SQLite history store
+	"fmt"          // for error wrapping
+	"math"         // for rounding sampled key sizes
+	"time"         // for run timestamps
+
+	_ "modernc.org/sqlite" // pure-Go SQLite driver, so CGO_ENABLED=0 builds keep working
+)
+
+// sqliteSchema creates the -sqlite tables if absent. A run is keyed by its
+// timestamp, label and git SHA; each run has one results row per size.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	ts             TEXT NOT NULL,
+	label          TEXT NOT NULL,
+	git_sha        TEXT NOT NULL,
+	client_version TEXT NOT NULL,
+	UNIQUE (ts, label, git_sha)
+);
+CREATE TABLE IF NOT EXISTS results (
+	run_id      INTEGER NOT NULL REFERENCES runs(id),
+	count       INTEGER NOT NULL,
+	distinct_n  INTEGER NOT NULL,
+	delta_mb    REAL    NOT NULL,
+	insert_ns   INTEGER NOT NULL,
+	direct_ns   INTEGER NOT NULL,
+	pipeline_ns INTEGER NOT NULL,
+	lua_ns      INTEGER NOT NULL,
+	PRIMARY KEY (run_id, count)
+);
+CREATE INDEX IF NOT EXISTS runs_ts ON runs (ts);
+`
+
+// sqliteAddedColumns are results columns added after the first schema.
+// ensureColumns adds any that an older file lacks; rows written before
+// the column existed read as 0.
+var sqliteAddedColumns = []string{
+	"write_direct_ns", "write_pipeline_ns", "write_lua_ns", "batch_ns", "concurrent_ns",
+	"json_bytes", "hash_bytes", "hget_ns", "hmget_ns", "hgetall_ns",
+}
+
+// ensureColumns adds the missing sqliteAddedColumns to the results table.
+func ensureColumns(db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, `SELECT name FROM pragma_table_info('results')`)
+	if err != nil {
+		return err
+	}
+	have := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		have[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, col := range sqliteAddedColumns {
+		if have[col] {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, `ALTER TABLE results ADD COLUMN `+col+` INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return fmt.Errorf("add column %s: %w", col, err)
+		}
+	}
+	return nil
+}
+
+// recordSQLite appends this run and its per-size results to the SQLite
+// database at cfg.SQLite in a single transaction, so an interrupted write
+// never leaves a run without its results. Trends can then be queried
+// locally, e.g. direct_ns by ts for one label.
+func recordSQLite(cfg Config, results []BenchResult) error {
+	db, err := sql.Open("sqlite", cfg.SQLite)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", cfg.SQLite, err)
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		return fmt.Errorf("create tables: %w", err)
+	}
+	if err := ensureColumns(db); err != nil {
+		return fmt.Errorf("migrate results table: %w", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback() // no-op once committed
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO runs (ts, label, git_sha, client_version) VALUES (?, ?, ?, ?)`,
+		time.Now().UTC().Format(time.RFC3339Nano), cfg.Label, gitSHA(), clientVersion())
+	if err != nil {
+		return fmt.Errorf("insert run: %w", err)
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("run id: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO results
+		(run_id, count, distinct_n, delta_mb, insert_ns, direct_ns, pipeline_ns, lua_ns,
+		 batch_ns, concurrent_ns, write_direct_ns, write_pipeline_ns, write_lua_ns,
+		 json_bytes, hash_bytes, hget_ns, hmget_ns, hgetall_ns)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("prepare results insert: %w", err)
+	}
+	defer stmt.Close()
+	for _, r := range results {
+		if _, err := stmt.ExecContext(ctx, runID, r.Count, r.Distinct, r.DeltaMB,
+			int64(r.Insert), int64(r.Direct), int64(r.Pipeline), int64(r.Lua),
+			int64(r.Batch), int64(r.Concurrent),
+			int64(r.WriteDirect), int64(r.WritePipeline), int64(r.WriteLua),
+			int64(math.Round(r.JSONBytes)), int64(math.Round(r.HashBytes)),
+			int64(r.HGet), int64(r.HMGet), int64(r.HGetAll)); err != nil {
+			return fmt.Errorf("insert results for size %d: %w", r.Count, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	fmt.Printf("🗄  Run %d (%d sizes) stored in %s\n", runID, len(results), cfg.SQLite)
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordSQLiteMigratesOldFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.db")
+
+	// A file written before the batch, concurrent and write columns existed
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	res := BenchResult{Count: 10, Direct: 1, Batch: 2 * time.Millisecond, Concurrent: 3, WriteLua: 4}
+	if err := recordSQLite(Config{SQLite: path, Label: "t"}, []BenchResult{res}); err != nil {
+		t.Fatal(err)
+	}
+	// A second run must not try to add the columns again
+	if err := recordSQLite(Config{SQLite: path, Label: "t2"}, []BenchResult{res}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var batch, concurrent, writeLua int64
+	var n int
+	if err := db.QueryRow(`SELECT count(*), max(batch_ns), max(concurrent_ns), max(write_lua_ns) FROM results`).
+		Scan(&n, &batch, &concurrent, &writeLua); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || batch != int64(res.Batch) || concurrent != 3 || writeLua != 4 {
+		t.Errorf("stored %d rows, batch %d concurrent %d write_lua %d", n, batch, concurrent, writeLua)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"math" // for rounding ranks and square roots
+	"sort" // for ordering samples
+	"time" // for durations
+)
+
+// PhaseStats aggregates the passes of one fetch phase.
+type PhaseStats struct {
+	Runs   int           `json:"runs"`
+	Mean   time.Duration `json:"mean_ns"`
+	Stddev time.Duration `json:"stddev_ns"` // sample standard deviation
+	P99    time.Duration `json:"p99_ns"`
+}
+
+// stats returns the mean, sample standard deviation and nearest-rank p99
+// of durations, which it leaves unmodified. All three are 0 for no
+// samples, and stddev is 0 for a single one.
+func stats(durations []time.Duration) (mean, stddev, p99 time.Duration) {
+	n := len(durations)
+	if n == 0 {
+		return 0, 0, 0
+	}
+	var sum float64
+	for _, d := range durations {
+		sum += float64(d)
+	}
+	m := sum / float64(n)
+	if n > 1 {
+		var sq float64
+		for _, d := range durations {
+			sq += (float64(d) - m) * (float64(d) - m)
+		}
+		stddev = time.Duration(math.Sqrt(sq / float64(n-1)))
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sortDurations(sorted)
+	return time.Duration(m), stddev, percentile(sorted, 99)
+}
+
+// sortDurations sorts samples in place, ascending.
+func sortDurations(samples []time.Duration) {
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+}
+
+// percentile returns the nearest-rank p-th percentile (0 < p <= 100) of
+// sorted, which must be in ascending order. It returns 0 for no samples.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"     // for the concurrent column title
+	"strings" // for listing strategy names
+	"time"    // for result durations
+)
+
+// fetchStrategy is one fetch phase measured at every size. Adding a
+// strategy is one more entry in fetchStrategies plus its BenchResult
+// field.
+type fetchStrategy struct {
+	name     string                                 // -strategies name and Stats/Failed key
+	title    func(cfg Config) string                // table column header
+	fetch    func(cfg Config, ds dataset) fetchFunc // the strategy, set up for one size
+	result   func(r *BenchResult) *time.Duration    // where its mean is stored
+	hashOnly bool                                   // reads only the hashes, so -recommend does not weigh it against full-record strategies
+}
+
+// fetchStrategies lists every -strategies entry in the order they run and
+// appear in the table.
+var fetchStrategies = []fetchStrategy{
+	// e) Direct fetch: n × (GET + HGET), decoding each value
+	{"direct", fixedTitle("Direct Fetch"),
+		func(cfg Config, _ dataset) fetchFunc { return decodingFetch(cfg) },
+		func(r *BenchResult) *time.Duration { return &r.Direct }, false},
+	// f) Pipeline fetch: batch GET + HGET in a single round-trip
+	{"pipeline", fixedTitle("Pipeline Fetch"),
+		func(cfg Config, _ dataset) fetchFunc { return pipelineFetch(cfg.PipeBatch) },
+		func(r *BenchResult) *time.Duration { return &r.Pipeline }, false},
+	// g) Lua fetch: server-side atomic GET + HGET
+	{"lua", fixedTitle("Lua Fetch"),
+		func(_ Config, ds dataset) fetchFunc { return luaFetch(ds.ttl > 0) },
+		func(r *BenchResult) *time.Duration { return &r.Lua }, false},
+	// h) Batch fetch: one MGET for the JSON values plus pipelined HGETs
+	{"batch", fixedTitle("Batch Fetch"),
+		func(_ Config, ds dataset) fetchFunc { return batchFetch(ds.ttl > 0) },
+		func(r *BenchResult) *time.Duration { return &r.Batch }, false},
+	// i) Concurrent fetch: direct GET + HGET + decode split across -workers
+	{"concurrent", func(cfg Config) string { return fmt.Sprintf("Concurrent (%d)", cfg.Workers) },
+		func(cfg Config, _ dataset) fetchFunc { return concurrentFetch(decodingFetch(cfg), cfg.Workers) },
+		func(r *BenchResult) *time.Duration { return &r.Concurrent }, false},
+	// j) HGET fetch: n × HGET of the email field alone, hashes only
+	{"hget", fixedTitle("HGET Fetch"),
+		func(Config, dataset) fetchFunc { return fetchHGet },
+		func(r *BenchResult) *time.Duration { return &r.HGet }, true},
+	// k) HMGET fetch: n × HMGET of name, email and amount, rebuilding the Record
+	{"hmget", fixedTitle("HMGET Fetch"),
+		func(Config, dataset) fetchFunc { return fetchHMGet },
+		func(r *BenchResult) *time.Duration { return &r.HMGet }, true},
+	// l) HGETALL fetch: n × HGETALL, rebuilding the Record from the map
+	{"hgetall", fixedTitle("HGETALL Fetch"),
+		func(Config, dataset) fetchFunc { return fetchHGetAll },
+		func(r *BenchResult) *time.Duration { return &r.HGetAll }, true},
+}
+
+// fixedTitle returns a column title that does not depend on cfg.
+func fixedTitle(s string) func(Config) string {
+	return func(Config) string { return s }
+}
+
+// strategyNames is the comma-separated list of every strategy, the
+// -strategies default.
+func strategyNames() string {
+	names := make([]string, len(fetchStrategies))
+	for i, s := range fetchStrategies {
+		names[i] = s.name
+	}
+	return strings.Join(names, ",")
+}
+
+// selectStrategies parses a -strategies list into the matching entries of
+// fetchStrategies, kept in their order whatever the order of the list.
+func selectStrategies(list string) ([]fetchStrategy, error) {
+	want := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if !isStrategy(name) {
+			return nil, fmt.Errorf("unknown strategy %q (have %s)", name, strategyNames())
+		}
+		want[name] = true
+	}
+	var out []fetchStrategy
+	for _, s := range fetchStrategies {
+		if want[s.name] {
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no strategies selected")
+	}
+	return out, nil
+}
+
+// isStrategy reports whether name is one of fetchStrategies.
+func isStrategy(name string) bool {
+	for _, s := range fetchStrategies {
+		if s.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// selectedStrategies returns the -strategies of cfg; a Config that never
+// went through parseFlags runs them all.
+func selectedStrategies(cfg Config) []fetchStrategy {
+	if cfg.Strategies == nil {
+		return fetchStrategies
+	}
+	return cfg.Strategies
+}
+
+// strategySelected reports whether strategy name is among the selected
+// -strategies.
+func strategySelected(cfg Config, name string) bool {
+	for _, s := range selectedStrategies(cfg) {
+		if s.name == name {
+			return true
+		}
+	}
+	return false
+}
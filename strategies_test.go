@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what fn printed to os.Stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestSelectStrategies(t *testing.T) {
+	got, err := selectStrategies(" lua, direct ,,lua")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].name != "direct" || got[1].name != "lua" {
+		t.Errorf("selectStrategies = %v, want [direct lua] in table order", got)
+	}
+	if all, err := selectStrategies(strategyNames()); err != nil || len(all) != len(fetchStrategies) {
+		t.Errorf("the default list selected %d strategies (%v), want all %d", len(all), err, len(fetchStrategies))
+	}
+	for _, list := range []string{"direct,bogus", "", " , "} {
+		if _, err := selectStrategies(list); err == nil {
+			t.Errorf("selectStrategies(%q) succeeded, want an error", list)
+		}
+	}
+}
+
+func TestRunSizeOnlySelectedStrategies(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	strategies, err := selectStrategies("pipeline,batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Runs: 1, Workers: 2, PipeBatch: 4, Strategies: strategies, Output: "table"}
+	src, err := openRecordSource(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res BenchResult
+	out := captureStdout(t, func() {
+		b := &benchRun{cfg: cfg, rdb: rdb, reader: rdb, src: src, sink: newTableSink(cfg, "test"), trackKeys: true}
+		res, err = b.runSize(10)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Pipeline <= 0 || res.Batch <= 0 || res.Direct != 0 || res.Lua != 0 || res.Concurrent != 0 {
+		t.Errorf("runSize ran %+v, want only pipeline and batch", res)
+	}
+	if !strings.Contains(out, "Pipeline Fetch") || !strings.Contains(out, "Batch Fetch") {
+		t.Errorf("table lacks the selected columns:\n%s", out)
+	}
+	for _, col := range []string{"Direct Fetch", "Lua Fetch", "Concurrent"} {
+		if strings.Contains(out, col) {
+			t.Errorf("table shows unselected column %q:\n%s", col, out)
+		}
+	}
+}
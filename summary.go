@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json" // for marshaling the summary
+	"fmt"           // for error wrapping
+	"os"            // for writing the summary file
+	"sync/atomic"   // for the retry counter
+	"time"          // for the run's wall-clock time
+)
+
+// runRetries counts the optimistic-locking retries of the whole run, such
+// as WATCH transactions re-run after a conflict.
+var runRetries atomic.Int64
+
+// RunSummary is the compact run-health document written by
+// -emit-exit-summary-json, for CI to decide pass/fail without parsing the
+// full results. Fatal errors still exit non-zero without writing one.
+type RunSummary struct {
+	Status        string        `json:"status"`               // "ok", "degraded" or "aborted"
+	Errors        int           `json:"errors"`               // errors collected during the run
+	SLOViolations int           `json:"slo_violations"`       // len(Violations)
+	Violations    []string      `json:"violations,omitempty"` // each timed-out phase and -fail-on-regress breach
+	Retries       int64         `json:"retries"`              // WATCH conflicts retried by the -watch and -counters workloads
+	Sizes         int           `json:"sizes"`                // sample sizes that completed
+	Runtime       time.Duration `json:"runtime_ns"`           // wall-clock time of the run
+	TimedOut      []string      `json:"timed_out,omitempty"`  // "phase@size" for each timed-out phase
+}
+
+// newRunSummary builds the summary for results. regression describes the
+// -fail-on-regress breach, "" for none. Each phase that hit -phase-timeout
+// and the breach count as SLO violations. A run is degraded when it has an
+// SLO violation or a collected error, and aborted when -max-memory-mb or
+// an interrupt stopped it. Retries alone do not degrade a run.
+func newRunSummary(results []BenchResult, aborted bool, failures int, regression string, runtime time.Duration) RunSummary {
+	s := RunSummary{Status: "ok", Errors: failures, Sizes: len(results), Runtime: runtime, Retries: runRetries.Load()}
+	for _, r := range results {
+		for _, p := range r.TimedOut {
+			s.TimedOut = append(s.TimedOut, fmt.Sprintf("%s@%d", p, r.Count))
+			s.Violations = append(s.Violations, fmt.Sprintf("%s@%d timed out", p, r.Count))
+		}
+	}
+	if regression != "" {
+		s.Violations = append(s.Violations, regression)
+	}
+	s.SLOViolations = len(s.Violations)
+	switch {
+	case aborted:
+		s.Status = "aborted"
+	case s.Errors > 0 || s.SLOViolations > 0:
+		s.Status = "degraded"
+	}
+	return s
+}
+
+// writeRunSummary writes s as one line of JSON to path, or to stderr if
+// path is "-".
+func writeRunSummary(path string, s RunSummary) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stderr.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing exit summary failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRunSummary(t *testing.T) {
+	defer runRetries.Store(runRetries.Load())
+	runRetries.Store(7)
+
+	timedOut := []BenchResult{{Count: 10}, {Count: 100, TimedOut: []string{"lua"}}}
+	tests := []struct {
+		name       string
+		results    []BenchResult
+		aborted    bool
+		failures   int
+		regression string
+		status     string
+		errors     int
+		violations int
+	}{
+		{"ok", []BenchResult{{Count: 10}}, false, 0, "", "ok", 0, 0},
+		{"timed out", timedOut, false, 0, "", "degraded", 0, 1},
+		{"regressed", []BenchResult{{Count: 10}}, false, 0, "slowest regression +12.0%", "degraded", 0, 1},
+		{"timed out and regressed", timedOut, false, 0, "slowest regression +12.0%", "degraded", 0, 2},
+		{"collected errors", []BenchResult{{Count: 10}}, false, 2, "", "degraded", 2, 0},
+		{"aborted", nil, true, 0, "", "aborted", 0, 0},
+	}
+	for _, tt := range tests {
+		s := newRunSummary(tt.results, tt.aborted, tt.failures, tt.regression, time.Second)
+		if s.Status != tt.status || s.Errors != tt.errors || s.SLOViolations != tt.violations ||
+			len(s.Violations) != tt.violations || s.Retries != 7 || s.Sizes != len(tt.results) {
+			t.Errorf("%s: got %+v", tt.name, s)
+		}
+	}
+}
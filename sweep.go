@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"     // for formatted I/O
+	"strings" // for chart bars
+	"time"    // for durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// sweepBatch is how many records one pipeline or Lua call fetches in the
+// concurrency sweep; direct calls always fetch one.
+const sweepBatch = 10
+
+// SweepPoint is one strategy measured at one concurrency level.
+type SweepPoint struct {
+	Workers    int           `json:"workers"`
+	Strategy   string        `json:"strategy"`
+	Records    int           `json:"records"`
+	Elapsed    time.Duration `json:"elapsed_ns"`
+	Throughput float64       `json:"records_per_sec"`
+	P99        time.Duration `json:"p99_ns"` // per-call latency
+}
+
+// runConcurrencySweep inserts -workload-size records once and fetches them
+// with every strategy at 1, 2, 4, ... up to -concurrency-sweep workers,
+// reporting throughput and per-call p99 at each level. Pipeline and Lua
+// calls carry sweepBatch records each, so every strategy has enough calls
+// to spread across the workers. Throughput that stops growing while p99
+// climbs marks a strategy's scaling knee.
+func runConcurrencySweep(rdb redis.UniversalClient, cfg Config) ([]SweepPoint, error) {
+	ds, err := insertRecords(rdb, cfg, &recordSource{}, cfg.WorkloadSize)
+	defer deleteInsertedKeys(rdb, ds.distinct)
+	if err != nil {
+		return nil, err
+	}
+
+	strategies := []struct {
+		name  string
+		fn    fetchFunc
+		batch int
+	}{
+		{"direct", decodingFetch(cfg), 1},
+		{"pipeline", fetchPipeline, sweepBatch},
+		{"lua", luaFetch(ds.ttl > 0), sweepBatch},
+	}
+	var points []SweepPoint
+	for _, workers := range sweepLevels(cfg.ConcurrencySweep) {
+		for _, s := range strategies {
+			dur, lat, err := fetchConcurrent(ctx, s.fn, rdb, ds.jsonKeys, ds.hashKeys, workers, s.batch)
+			if err != nil {
+				return nil, err
+			}
+			sortDurations(lat)
+			points = append(points, SweepPoint{
+				Workers:    workers,
+				Strategy:   s.name,
+				Records:    len(ds.jsonKeys),
+				Elapsed:    dur,
+				Throughput: float64(len(ds.jsonKeys)) / dur.Seconds(),
+				P99:        percentile(lat, 99),
+			})
+		}
+	}
+	return points, nil
+}
+
+// sweepLevels returns 1, 2, 4, ... up to max, always ending with max.
+func sweepLevels(max int) []int {
+	var levels []int
+	for w := 1; w < max; w *= 2 {
+		levels = append(levels, w)
+	}
+	return append(levels, max)
+}
+
+// printSweep prints points as a table with a throughput bar per row.
+// With -output csv or json the points go into the render document instead.
+func printSweep(points []SweepPoint) {
+	var peak float64
+	for _, p := range points {
+		if p.Throughput > peak {
+			peak = p.Throughput
+		}
+	}
+	fmt.Println("Concurrency sweep")
+	fmt.Println("Workers | Strategy | Records/s  | p99          |")
+	fmt.Println("--------+----------+------------+--------------+")
+	for _, p := range points {
+		fmt.Printf("%7d | %-8s | %10.0f | %12v | %s\n",
+			p.Workers, p.Strategy, p.Throughput, p.P99,
+			strings.Repeat("█", int(40*p.Throughput/peak)))
+	}
+}
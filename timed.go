@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors" // for recognising partial fetches
+	"fmt"    // for formatted I/O
+	"time"   // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// TimedResult summarises one strategy run continuously for -duration.
+type TimedResult struct {
+	Strategy string        `json:"strategy"`
+	Ops      int           `json:"ops"`     // calls completed in the window
+	Records  int           `json:"records"` // records fetched in the window
+	Elapsed  time.Duration `json:"elapsed_ns"`
+	P50      time.Duration `json:"p50_ns"` // per-call latency percentiles
+	P99      time.Duration `json:"p99_ns"`
+	P999     time.Duration `json:"p999_ns"`
+	Failed   int           `json:"failed,omitempty"` // records lost to per-record errors
+}
+
+// runTimedFetches runs each selected fetch strategy back-to-back for
+// -duration, recording every call's latency. A direct call fetches and
+// decodes one record (cycling through the dataset), as the counted direct
+// strategy does; pipeline and Lua calls fetch the
+// whole dataset, the pipeline in chunks of -pipe-batch records.
+func runTimedFetches(cfg Config, rdb redis.UniversalClient, ds dataset) ([]TimedResult, error) {
+	window := cfg.Duration
+	direct := decodingFetch(cfg)
+	pipeline := pipelineFetch(cfg.PipeBatch)
+	lua := luaFetch(ds.ttl > 0)
+	n := len(ds.jsonKeys)
+	strategies := []struct {
+		name    string
+		perCall int
+		call    func(i int) error
+	}{
+		{"direct", 1, func(i int) error {
+			j := i % n
+			_, err := direct(ctx, rdb, ds.jsonKeys[j:j+1], ds.hashKeys[j:j+1])
+			return err
+		}},
+		{"pipeline", n, func(int) error {
+			_, err := pipeline(ctx, rdb, ds.jsonKeys, ds.hashKeys)
+			return err
+		}},
+		{"lua", n, func(int) error {
+			_, err := lua(ctx, rdb, ds.jsonKeys, ds.hashKeys)
+			return err
+		}},
+	}
+
+	var out []TimedResult
+	for _, s := range strategies {
+		if !strategySelected(cfg, s.name) {
+			continue
+		}
+		var samples []time.Duration
+		failed := 0
+		start := time.Now()
+		deadline := start.Add(window)
+		for i := 0; time.Now().Before(deadline); i++ {
+			t0 := time.Now()
+			if err := s.call(i); err != nil {
+				var partial *PartialFetchError
+				if !errors.As(err, &partial) {
+					return nil, err
+				}
+				failed += partial.Failed
+				continue
+			}
+			samples = append(samples, time.Since(t0))
+		}
+		elapsed := time.Since(start)
+		sortDurations(samples)
+		out = append(out, TimedResult{
+			Strategy: s.name,
+			Ops:      len(samples),
+			Records:  len(samples) * s.perCall,
+			Elapsed:  elapsed,
+			P50:      percentile(samples, 50),
+			P99:      percentile(samples, 99),
+			P999:     percentile(samples, 99.9),
+			Failed:   failed,
+		})
+	}
+	return out, nil
+}
+
+// printTimedHeader prints the table header used in -duration mode.
+func printTimedHeader() {
+	fmt.Println("Count   | ΔMem (MB) | Strategy | Ops      | Records/s  | p50          | p99          | p999")
+	fmt.Println("--------+-----------+----------+----------+------------+--------------+--------------+-------------")
+}
+
+// printTimed prints one row per strategy for a size in -duration mode.
+func printTimed(n int, deltaMB float64, timed []TimedResult) {
+	for _, t := range timed {
+		fmt.Printf("%6d | %+9.2f | %-8s | %8d | %10.0f | %12v | %12v | %12v\n",
+			n, deltaMB, t.Strategy, t.Ops, float64(t.Records)/t.Elapsed.Seconds(),
+			t.P50, t.P99, t.P999)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"  // for formatted I/O
+	"time" // for waiting out the TTL
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// verifyExpired sleeps for ttl and then checks with EXISTS, in batches,
+// that none of keys is left. Every write path arms the expiry before the
+// fetch phases start, so a full ttl from now is always past it. EXISTS on
+// an expired key deletes it, so the check does not depend on how far the
+// server's active expiry cycle has got.
+func verifyExpired(rdb redis.UniversalClient, keys []string, ttl time.Duration) error {
+	fmt.Printf("⏳ Waiting %v for %d keys to expire...\n", ttl, len(keys))
+	time.Sleep(ttl)
+	const batchSize = 1000
+	var left int64
+	for i := 0; i < len(keys); i += batchSize {
+		end := i + batchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		var n int64
+		var err error
+		if isCluster(rdb) {
+			n, err = countBySlot(rdb, keys[i:end], redis.Pipeliner.Exists)
+		} else {
+			n, err = rdb.Exists(ctx, keys[i:end]...).Result()
+		}
+		if err != nil {
+			return fmt.Errorf("EXISTS on keys %d–%d failed: %w", i, end, err)
+		}
+		left += n
+	}
+	if left > 0 {
+		return fmt.Errorf("%d of %d keys still exist %v after they were written with -ttl", left, len(keys), ttl)
+	}
+	fmt.Printf("✅ TTL verified: all %d keys expired\n", len(keys))
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"  // for matching TxFailedErr
+	"fmt"     // for formatted I/O
+	"strconv" // for the counter value
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// runWatch has cfg.Writers goroutines each perform their share of
+// cfg.WorkloadSize read-modify-write updates on one shared key using
+// optimistic locking: WATCH the key, GET it, then MULTI/SET/EXEC. EXEC
+// returns nil (redis.TxFailedErr) whenever another writer changed the key
+// in between, and the update is retried. The final value proves no update
+// was lost.
+func runWatch(rdb redis.UniversalClient, cfg Config) error {
+	key := keyPrefix + "watch:counter"
+	defer deleteInsertedKeys(rdb, []string{key})
+	if err := rdb.Set(ctx, key, 0, 0).Err(); err != nil {
+		return fmt.Errorf("SET %s failed: %w", key, err)
+	}
+
+	perWriter := cfg.WorkloadSize / cfg.Writers
+	if perWriter == 0 {
+		perWriter = 1
+	}
+	total := perWriter * cfg.Writers
+
+	dur, conflicts, err := concurrentIncr(cfg.Writers, perWriter, func() (int, error) {
+		return watchIncr(rdb, key)
+	})
+	if err != nil {
+		return err
+	}
+
+	final, err := rdb.Get(ctx, key).Int()
+	if err != nil {
+		return fmt.Errorf("GET %s failed: %w", key, err)
+	}
+	attempts := total + conflicts
+	fmt.Println("Optimistic locking: WATCH/MULTI/EXEC")
+	fmt.Printf("  %d writers × %d updates | %v | %.0f updates/s\n",
+		cfg.Writers, perWriter, dur, float64(total)/dur.Seconds())
+	fmt.Printf("  %d conflicts in %d EXECs (%.1f%% retried) | final value %d/%d\n",
+		conflicts, attempts, 100*float64(conflicts)/float64(attempts), final, total)
+	if final != total {
+		return fmt.Errorf("counter is %d after %d updates: an update was lost", final, total)
+	}
+	return nil
+}
+
+// watchIncr adds one to the integer at key with WATCH/GET/MULTI/SET/EXEC,
+// retrying until EXEC succeeds, and returns how many attempts conflicted.
+func watchIncr(rdb redis.UniversalClient, key string) (conflicts int, err error) {
+	for {
+		err := rdb.Watch(ctx, func(tx *redis.Tx) error {
+			v, err := tx.Get(ctx, key).Int()
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, strconv.Itoa(v+1), 0)
+				return nil
+			})
+			return err
+		}, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			conflicts++
+			continue
+		}
+		if err != nil {
+			return conflicts, fmt.Errorf("WATCH update of %s failed: %w", key, err)
+		}
+		return conflicts, nil
+	}
+}
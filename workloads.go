@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json" // for marshaling Record structs
+	"fmt"           // for wrapping errors
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// workload is an optional benchmark that runs after the main size loop.
+// Each workload creates its own keys under keyPrefix and deletes them again
+// before returning.
+type workload struct {
+	name    string
+	enabled bool
+	run     func(rdb redis.UniversalClient, cfg Config) error
+}
+
+// workloads lists every optional workload in the order they run.
+func workloads(cfg Config) []workload {
+	return []workload{
+		{"getex", cfg.GetEx, runGetEx},
+		{"capped-list", cfg.CappedList, runCappedList},
+		{"eval-per-key", cfg.EvalPerKey, runEvalPerKey},
+		{"pipeline-errors", cfg.PipelineErrors, runPipelineErrors},
+		{"random-access", cfg.RandomAccess, runRandomAccess},
+		{"bitfield", cfg.Bitfield, runBitfield},
+		{"lcs", cfg.LCS, runLCS},
+		{"encoding-transitions", cfg.EncodingTransitions, runEncodingTransitions},
+		{"lua-hmget", cfg.LuaHMGet, runLuaHMGet},
+		{"aof-compare", cfg.AOFCompare, runAOFCompare},
+		{"watch", cfg.Watch, runWatch},
+		{"hset-struct", cfg.HSetStruct, runHSetStruct},
+		{"client-name-bench", cfg.ClientNameBench, runClientNameBench},
+		{"sort", cfg.Sort, runSort},
+		{"pipeline-flush", cfg.PipelineFlush, runPipelineFlush},
+		{"type-memory", cfg.TypeMemory, runTypeMemory},
+		{"nodelay-compare", cfg.NoDelayCompare, runNoDelayCompare},
+		{"counters", cfg.Counters, runCounters},
+		{"prefix-scan", cfg.PrefixScan, runPrefixScan},
+		{"envelope-compare", cfg.EnvelopeCompare, runEnvelopeCompare},
+		{"zadd-gt", cfg.ZAddGT, runZAddGT},
+		{"rename", cfg.Rename, runRename},
+		{"mget-sweep", cfg.MGetSweep, runMGetSweep},
+		{"copy", cfg.Copy, runCopy},
+		{"smismember", cfg.SMIsMember, runSMIsMember},
+		{"compress-compare", cfg.Compress, runCompressCompare},
+		{"pool-sweep", len(cfg.PoolSizes) > 0, runPoolSweep},
+	}
+}
+
+// singleNodeWorkloads are the workloads that cannot run against a cluster:
+// they send commands over several untagged keys (MGET, EVALSHA, LCS,
+// RENAME, SORT BY) or change server config on whichever node answers.
+var singleNodeWorkloads = map[string]bool{
+	"eval-per-key": true,
+	"bitfield":     true,
+	"lcs":          true,
+	"lua-hmget":    true,
+	"aof-compare":  true,
+	"sort":         true,
+	"rename":       true,
+	"mget-sweep":   true,
+}
+
+// clusterUnsafeWorkload returns the name of the first enabled workload in
+// singleNodeWorkloads, or "" when every enabled workload is cluster-safe.
+func clusterUnsafeWorkload(cfg Config) string {
+	for _, w := range workloads(cfg) {
+		if w.enabled && singleNodeWorkloads[w.name] {
+			return w.name
+		}
+	}
+	return ""
+}
+
+// seedJSONKeys stores n generated records as JSON strings under
+// prefix+<UUID> using a single pipeline, and returns the keys written. On
+// error it still returns every key queued, since some SETs may have
+// landed, so the caller can defer their cleanup before checking err.
+func seedJSONKeys(rdb redis.UniversalClient, prefix string, n int) ([]string, error) {
+	keys := make([]string, n)
+	pipe := rdb.Pipeline()
+	for i := 0; i < n; i++ {
+		rec := generateRecord()
+		data, _ := json.Marshal(rec)
+		keys[i] = prefix + rec.ID
+		pipe.Set(ctx, keys[i], data, 0)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return keys, fmt.Errorf("seeding %s* failed: %w", prefix, err)
+	}
+	return keys, nil
+}
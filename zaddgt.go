@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"     // for formatted I/O
+	"strconv" // for player names
+	"time"    // for measuring durations
+
+	"github.com/go-redis/redis/v8" // Redis client
+)
+
+// runZAddGT maintains a leaderboard of -workload-size players and applies
+// ten score updates per player, once with plain ZADD and once with ZADD GT
+// CH, which keeps a player's best score instead of the latest one. CH makes
+// ZADD report changed members, so the GT run counts the updates its
+// condition skipped. Both boards are checked against the expected scores.
+func runZAddGT(rdb redis.UniversalClient, cfg Config) error {
+	ok, err := versionAtLeast(rdb, "6.2")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("⏭  ZADD GT workload skipped: requires Redis >= 6.2")
+		return nil
+	}
+
+	players := cfg.WorkloadSize
+	updates := make([]redis.Z, 10*players)
+	for i := range updates {
+		updates[i] = redis.Z{Score: float64(randInt(0, 1_000_000)), Member: "p" + strconv.Itoa(randInt(0, players))}
+	}
+	// Every player starts at 0, so the expected boards are the last and
+	// the highest score seen per player
+	last := make(map[string]float64, players)
+	best := make(map[string]float64, players)
+	for _, u := range updates {
+		m := u.Member.(string)
+		last[m] = u.Score
+		if u.Score > best[m] {
+			best[m] = u.Score
+		}
+	}
+
+	runs := []struct {
+		name string
+		key  string
+		gt   bool
+		want map[string]float64
+	}{
+		{"ZADD", keyPrefix + "zadd:plain", false, last},
+		{"ZADD GT", keyPrefix + "zadd:gt", true, best},
+	}
+	defer deleteInsertedKeys(rdb, []string{runs[0].key, runs[1].key})
+
+	fmt.Printf("Leaderboard updates (%d players, %d updates)\n", players, len(updates))
+	for _, run := range runs {
+		seed := make([]*redis.Z, players)
+		for i := range seed {
+			seed[i] = &redis.Z{Member: "p" + strconv.Itoa(i)}
+		}
+		if err := rdb.ZAdd(ctx, run.key, seed...).Err(); err != nil {
+			return fmt.Errorf("seeding %s failed: %w", run.key, err)
+		}
+		changed := 0
+		t0 := time.Now()
+		for _, u := range updates {
+			n, err := rdb.ZAddArgs(ctx, run.key, redis.ZAddArgs{GT: run.gt, Ch: true, Members: []redis.Z{u}}).Result()
+			if err != nil {
+				return fmt.Errorf("%s %s failed: %w", run.name, run.key, err)
+			}
+			changed += int(n)
+		}
+		dur := time.Since(t0)
+
+		if err := checkLeaderboard(rdb, run.key, run.want); err != nil {
+			return fmt.Errorf("%s: %w", run.name, err)
+		}
+		skipped := len(updates) - changed
+		fmt.Printf("  %-7s | %12v | %.0f updates/s | %d skipped (%.1f%%)\n",
+			run.name, dur, float64(len(updates))/dur.Seconds(),
+			skipped, 100*float64(skipped)/float64(len(updates)))
+	}
+	fmt.Println("  plain ZADD skips only updates that repeat the current score")
+	return nil
+}
+
+// checkLeaderboard verifies that every player in want has that score in
+// the sorted set at key; players missing from want must still score 0.
+func checkLeaderboard(rdb redis.UniversalClient, key string, want map[string]float64) error {
+	board, err := rdb.ZRangeWithScores(ctx, key, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("ZRANGE %s failed: %w", key, err)
+	}
+	for _, z := range board {
+		m := z.Member.(string)
+		if z.Score != want[m] {
+			return fmt.Errorf("%s scores %v, want %v", m, z.Score, want[m])
+		}
+	}
+	return nil
+}